@@ -17,6 +17,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"regexp"
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -29,33 +32,162 @@ type ResourceRef struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// DeletionPolicy defines what happens to the properties pushed to GitHub when the resource is deleted
+// +kubebuilder:validation:Enum=Delete;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete removes from GitHub the properties pushed by the operator
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the properties on GitHub, the operator only stops tracking them
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// NameCase defines how keys are transformed into GitHub names
+// +kubebuilder:validation:Enum=Upper;Lower
+type NameCase string
+
+const (
+	// NameCaseUpper uppercases the key, replacing characters GitHub refuses with underscores: db-password becomes DB_PASSWORD
+	NameCaseUpper NameCase = "Upper"
+	// NameCaseLower lowercases the key, replacing characters GitHub refuses with underscores
+	NameCaseLower NameCase = "Lower"
+)
+
+// KeyExpansion defines how the keys of a Kubernetes object are selected and named on GitHub
+type KeyExpansion struct {
+	// KeyRegex selects every key of the Kubernetes object matching it, ".*" selecting them all, instead of a single Key
+	// +optional
+	KeyRegex string `json:"keyRegex,omitempty"`
+	// Prefix is prepended to the keys to build the GitHub names
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Suffix is appended to the keys to build the GitHub names
+	// +optional
+	Suffix string `json:"suffix,omitempty"`
+	// NameCase transforms the keys before building the GitHub names, which are left as is if not set
+	// +optional
+	NameCase NameCase `json:"nameCase,omitempty"`
+}
+
+// GithubNameOf builds the GitHub name of a key
+func (e KeyExpansion) GithubNameOf(key string) string {
+	switch e.NameCase {
+	case NameCaseUpper:
+		key = strings.ToUpper(githubNameUnsafeChars.ReplaceAllString(key, "_"))
+	case NameCaseLower:
+		key = strings.ToLower(githubNameUnsafeChars.ReplaceAllString(key, "_"))
+	}
+	return e.Prefix + key + e.Suffix
+}
+
+// Characters GitHub refuses in names
+var githubNameUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// TemplateValue names a key of a Kubernetes Secret or ConfigMap for a template to use
+// +kubebuilder:validation:XValidation:rule="has(self.secretRef) != has(self.configMapRef)",message="exactly one of secretRef or configMapRef must be set"
+type TemplateValue struct {
+	// Name is how the template refers to the value, as {{ .name }}
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+	// SecretRef is the Kubernetes Secret containing the value
+	// +optional
+	SecretRef *ResourceRef `json:"secretRef,omitempty"`
+	// ConfigMapRef is the Kubernetes ConfigMap containing the value
+	// +optional
+	ConfigMapRef *ResourceRef `json:"configMapRef,omitempty"`
+	// Key is the key in the Kubernetes Secret or ConfigMap to use
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+}
+
+// ValueTemplate composes a value from several keys of Kubernetes Secrets and ConfigMaps
+type ValueTemplate struct {
+	// Text is a Go text/template rendered with the values by name.
+	// Besides built-in functions, b64enc base64-encodes and toJson quotes as a JSON string.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Text string `json:"text"`
+	// Values are the keys the template can use
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Values []TemplateValue `json:"values"`
+}
+
 // SecretRef defines a reference to a Kubernetes Secret and how to map it to a GitHub Secret
+// +kubebuilder:validation:XValidation:rule="has(self.secretRef) != has(self.template)",message="exactly one of secretRef or template must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.secretRef) || has(self.key) != has(self.keyRegex)",message="exactly one of key or keyRegex must be set along secretRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.githubSecretName) || has(self.key) || has(self.template)",message="githubSecretName can only be set along key or template"
+// +kubebuilder:validation:XValidation:rule="!has(self.template) || has(self.githubSecretName)",message="githubSecretName is required along template"
 type SecretRef struct {
 	// SecretRef is the name of the Kubernetes Secret containing the value
-	SecretRef ResourceRef `json:"secretRef"`
+	// +optional
+	SecretRef *ResourceRef `json:"secretRef,omitempty"`
+	// Template renders the value from several Kubernetes Secret and ConfigMap keys, instead of a single Secret
+	// +optional
+	Template *ValueTemplate `json:"template,omitempty"`
 	// Key is the key in the Kubernetes Secret to use
-	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
-	Key string `json:"key"`
+	// +optional
+	Key string `json:"key,omitempty"`
 	// GithubSecretName is the name to use for the GitHub Secret (defaults to Key if not set)
 	// +optional
 	GithubSecretName string `json:"githubSecretName,omitempty"`
+	KeyExpansion     `json:",inline"`
 }
 
 // VariableRef defines a reference to a Kubernetes ConfigMap and how to map it to a GitHub Variable
+// +kubebuilder:validation:XValidation:rule="has(self.configMapRef) != has(self.value)",message="exactly one of configMapRef or value must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.configMapRef) || has(self.key) != has(self.keyRegex)",message="exactly one of key or keyRegex must be set along configMapRef"
+// +kubebuilder:validation:XValidation:rule="!has(self.githubVariableName) || has(self.key) || has(self.value)",message="githubVariableName can only be set along key or value"
+// +kubebuilder:validation:XValidation:rule="!has(self.value) || has(self.githubVariableName)",message="githubVariableName is required along value"
 type VariableRef struct {
 	// ConfigMapRef is the name of the Kubernetes ConfigMap containing the value
-	ConfigMapRef ResourceRef `json:"configMapRef"`
+	// +optional
+	ConfigMapRef *ResourceRef `json:"configMapRef,omitempty"`
+	// Value is the literal value of the GitHub Variable, instead of a ConfigMap
+	// +optional
+	Value string `json:"value,omitempty"`
 	// Key is the key in the Kubernetes ConfigMap to use
-	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
-	Key string `json:"key"`
+	// +optional
+	Key string `json:"key,omitempty"`
 	// GithubVariableName is the name to use for the GitHub Variable (defaults to Key if not set)
 	// +optional
 	GithubVariableName string `json:"githubVariableName,omitempty"`
+	KeyExpansion       `json:",inline"`
+}
+
+// GithubName is the name of the GitHub Secret of the single key
+func (r SecretRef) GithubName() string {
+	return r.GithubNameOf(r.Key)
+}
+
+// GithubNameOf is the name of the GitHub Secret of a key, the explicit name winning
+func (r SecretRef) GithubNameOf(key string) string {
+	if r.GithubSecretName != "" {
+		return r.GithubSecretName
+	}
+	return r.KeyExpansion.GithubNameOf(key)
+}
+
+// GithubName is the name of the GitHub Variable of the single key
+func (r VariableRef) GithubName() string {
+	return r.GithubNameOf(r.Key)
+}
+
+// GithubNameOf is the name of the GitHub Variable of a key, the explicit name winning
+func (r VariableRef) GithubNameOf(key string) string {
+	if r.GithubVariableName != "" {
+		return r.GithubVariableName
+	}
+	return r.KeyExpansion.GithubNameOf(key)
 }
 
 // GithubActionSecretsSyncSpec defines the desired state of GithubActionSecretsSync
@@ -66,11 +198,74 @@ type GithubActionSecretsSyncSpec struct {
 	// Variables is a list of Kubernetes ConfigMaps to sync to GitHub Variables
 	// +optional
 	Variables []VariableRef `json:"variables,omitempty"`
+	// DependabotSecrets is a list of Kubernetes Secrets to sync to GitHub Dependabot Secrets
+	// +optional
+	DependabotSecrets []SecretRef `json:"dependabotSecrets,omitempty"`
+	// CodespacesSecrets is a list of Kubernetes Secrets to sync to GitHub Codespaces Secrets
+	// +optional
+	CodespacesSecrets []SecretRef `json:"codespacesSecrets,omitempty"`
+	// RepoSelector applies this sync to the GithubSyncRepo whose labels match, in addition to those referencing it by name
+	// +optional
+	RepoSelector *metav1.LabelSelector `json:"repoSelector,omitempty"`
+	// Priority decides which sync wins a property also defined by other syncs applied to a same repository, the highest winning.
+	// Syncs of equal priority are ordered by name.
+	// +kubebuilder:default=0
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+	// DeletionPolicy defines if the properties pushed to the repositories are removed from GitHub when this resource is deleted
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// SyncInterval is how often values are re-applied to the repositories, healing drift along the way.
+	// Defaults to the operator-wide interval, "0s" disabling periodic resync.
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+	// DryRun records in the repositories status the changes this sync would make on GitHub, without making them
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+	// Suspend stops syncing this sync to any repository, including when they are reconciled; deletion still cleans up
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// RepositorySyncOutcome defines how the last sync run went on a repository
+// +kubebuilder:validation:Enum=Synced;Failed;Pending;Suspended
+type RepositorySyncOutcome string
+
+const (
+	// RepositorySyncOutcomeSynced means every property was synced to the repository
+	RepositorySyncOutcomeSynced RepositorySyncOutcome = "Synced"
+	// RepositorySyncOutcomeFailed means some properties could not be synced to the repository
+	RepositorySyncOutcomeFailed RepositorySyncOutcome = "Failed"
+	// RepositorySyncOutcomeSuspended means the repository is suspended, and was left untouched
+	RepositorySyncOutcomeSuspended RepositorySyncOutcome = "Suspended"
+	// RepositorySyncOutcomePending means the last sync run did not reach the repository, postponed such as by GitHub API rate limit
+	RepositorySyncOutcomePending RepositorySyncOutcome = "Pending"
+)
+
+// RepositorySyncStatus defines the outcome of the last sync run on a repository this sync applies to
+type RepositorySyncStatus struct {
+	// Name is the name of the GithubSyncRepo
+	Name string `json:"name"`
+	// Outcome is how the last sync run went on the repository
+	Outcome RepositorySyncOutcome `json:"outcome"`
+	// SyncedCount is the number of properties of this sync synced to the repository
+	// +optional
+	SyncedCount int32 `json:"syncedCount,omitempty"`
+	// FailedCount is the number of properties of this sync that failed to sync to the repository
+	// +optional
+	FailedCount int32 `json:"failedCount,omitempty"`
+	// Message tells about the outcome, such as errors
+	// +optional
+	Message string `json:"message,omitempty"`
+	// LastSyncTime is since when every property of this sync is synced to the repository
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
 }
 
 // GithubActionSecretsSyncStatus defines the observed state of GithubActionSecretsSync
 type GithubActionSecretsSyncStatus struct {
-	// LastSyncTime is the last time the secrets were synced
+	// LastSyncTime is since when the secrets are synced to every repository
 	// +optional
 	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
 	// Conditions represent the latest available observations of the sync state
@@ -79,12 +274,19 @@ type GithubActionSecretsSyncStatus struct {
 	// ErrorMessage contains the last error message if sync failed
 	// +optional
 	ErrorMessage string `json:"errorMessage,omitempty"`
+	// Repositories lists the outcome of the last sync run on each repository this sync applies to
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Repositories []RepositorySyncStatus `json:"repositories,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Suspended",type="string",JSONPath=".status.conditions[?(@.type=='Suspended')].status"
 // +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
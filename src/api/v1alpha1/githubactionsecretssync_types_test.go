@@ -0,0 +1,31 @@
+package v1alpha1
+
+import "testing"
+
+func TestKeyExpansionGithubNameOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		expansion KeyExpansion
+		key       string
+		want      string
+	}{
+		{name: "key kept as is", key: "db-password", want: "db-password"},
+		{name: "upper case", expansion: KeyExpansion{NameCase: NameCaseUpper}, key: "db-password", want: "DB_PASSWORD"},
+		{name: "lower case", expansion: KeyExpansion{NameCase: NameCaseLower}, key: "DB.Password", want: "db_password"},
+		{name: "unsafe characters replaced", expansion: KeyExpansion{NameCase: NameCaseUpper}, key: "a.b-c/d e", want: "A_B_C_D_E"},
+		{
+			name:      "prefix and suffix kept out of case",
+			expansion: KeyExpansion{NameCase: NameCaseUpper, Prefix: "app_", Suffix: "_v2"},
+			key:       "token",
+			want:      "app_TOKEN_v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expansion.GithubNameOf(tt.key); got != tt.want {
+				t.Errorf("GithubNameOf(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025 Guillaume Vara.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RepositoryFilter defines which repositories accessible to the GitHub App installation are discovered
+type RepositoryFilter struct {
+	// NameRegex keeps the repositories whose full name (org/repo) matches this regular expression
+	// +optional
+	NameRegex string `json:"nameRegex,omitempty"`
+	// Topics keeps the repositories having all of these topics
+	// +optional
+	Topics []string `json:"topics,omitempty"`
+	// Visibilities keeps the repositories having one of these visibilities
+	// +kubebuilder:validation:items:Enum=public;private;internal
+	// +optional
+	Visibilities []string `json:"visibilities,omitempty"`
+	// IncludeArchived keeps archived repositories, which are left out by default
+	// +optional
+	IncludeArchived bool `json:"includeArchived,omitempty"`
+}
+
+// GithubSyncRepoTemplate defines the GithubSyncRepo created for each discovered repository
+type GithubSyncRepoTemplate struct {
+	// Labels to set on created GithubSyncRepo, handy to be matched by a repoSelector
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// SecretsSyncRefs is a list of GithubActionSecretsSync names to apply to discovered repositories
+	// +optional
+	SecretsSyncRefs []string `json:"secretsSyncRefs,omitempty"`
+	// SyncSelector applies the GithubActionSecretsSync whose labels match to discovered repositories
+	// +optional
+	SyncSelector *metav1.LabelSelector `json:"syncSelector,omitempty"`
+	// Prune deletes from GitHub the properties previously pushed by the operator that are no longer part of the desired state
+	// +kubebuilder:default=true
+	// +optional
+	Prune *bool `json:"prune,omitempty"`
+	// DeletionPolicy defines if the properties pushed are removed from GitHub when a repository stops being discovered
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// SyncInterval is how often values are re-applied to the repository, healing drift along the way.
+	// Defaults to the operator-wide interval, "0s" disabling periodic resync.
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+}
+
+// GithubRepoDiscoverySpec defines the desired state of GithubRepoDiscovery
+type GithubRepoDiscoverySpec struct {
+	// Filter restricts which repositories are discovered, all of them if empty
+	// +optional
+	Filter RepositoryFilter `json:"filter,omitempty"`
+	// Template of the GithubSyncRepo created for each discovered repository
+	// +kubebuilder:validation:Required
+	Template GithubSyncRepoTemplate `json:"template"`
+	// Interval between two discoveries against GitHub API
+	// +kubebuilder:default="10m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// GithubRepoDiscoveryStatus defines the observed state of GithubRepoDiscovery
+type GithubRepoDiscoveryStatus struct {
+	// DiscoveredRepositories lists the full names (org/repo) of the repositories discovered, whose GithubSyncRepo was applied
+	// +optional
+	DiscoveredRepositories []string `json:"discoveredRepositories,omitempty"`
+	// LastDiscoveryTime is the last time repositories were discovered
+	// +optional
+	LastDiscoveryTime *metav1.Time `json:"lastDiscoveryTime,omitempty"`
+	// Conditions represent the latest available observations of the discovery state
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="Last Discovery",type="date",JSONPath=".status.lastDiscoveryTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// GithubRepoDiscovery is the Schema for the githubrepodiscoveries API.
+type GithubRepoDiscovery struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GithubRepoDiscoverySpec   `json:"spec,omitempty"`
+	Status GithubRepoDiscoveryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GithubRepoDiscoveryList contains a list of GithubRepoDiscovery.
+type GithubRepoDiscoveryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GithubRepoDiscovery `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GithubRepoDiscovery{}, &GithubRepoDiscoveryList{})
+}
@@ -23,16 +23,64 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// OrganizationTarget defines an organization whose organization-level secrets and variables are synced
+// +kubebuilder:validation:XValidation:rule="!has(self.selectedRepositories) || self.visibility == 'selected'",message="selectedRepositories requires visibility to be 'selected'"
+type OrganizationTarget struct {
+	// Name is the login of the GitHub organization
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9-_]+$`
+	Name string `json:"name"`
+	// Visibility defines which repositories of the organization can access the secrets and variables
+	// +kubebuilder:validation:Enum=all;private;selected
+	// +kubebuilder:default=all
+	// +optional
+	Visibility string `json:"visibility,omitempty"`
+	// SelectedRepositories lists the names (without owner) of the repositories allowed to access the secrets and variables, when visibility is 'selected'
+	// +optional
+	SelectedRepositories []string `json:"selectedRepositories,omitempty"`
+}
+
 // GithubSyncRepoSpec defines the desired state of GithubSyncRepo
+// +kubebuilder:validation:XValidation:rule="has(self.repository) != has(self.organization)",message="exactly one of repository or organization must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.environment) || has(self.repository)",message="environment requires repository to be set"
 type GithubSyncRepoSpec struct {
 	// Repository is the full name of the GitHub repository (org/repo)
-	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9-_]+/[a-zA-Z0-9-_\.]+$`
-	Repository string `json:"repository"`
+	// +optional
+	Repository string `json:"repository,omitempty"`
+	// Organization targets organization-level secrets and variables instead of a repository (requires a paid GitHub plan)
+	// +optional
+	Organization *OrganizationTarget `json:"organization,omitempty"`
+	// Environment scopes secrets and variables to a deployment environment of the repository, created if missing
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	Environment string `json:"environment,omitempty"`
 	// SecretsSyncRefs is a list of GithubActionSecretsSync names to apply to this repository
 	// +optional
 	SecretsSyncRefs []string `json:"secretsSyncRefs,omitempty"`
+	// SyncSelector applies the GithubActionSecretsSync whose labels match to this repository, in addition to SecretsSyncRefs
+	// +optional
+	SyncSelector *metav1.LabelSelector `json:"syncSelector,omitempty"`
+	// Prune deletes from GitHub the properties previously pushed by the operator that are no longer part of the desired state
+	// +kubebuilder:default=true
+	// +optional
+	Prune *bool `json:"prune,omitempty"`
+	// DeletionPolicy defines if the properties pushed to the repository are removed from GitHub when this resource is deleted
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// SyncInterval is how often values are re-applied to the repository, healing drift along the way.
+	// Defaults to the operator-wide interval, "0s" disabling periodic resync.
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+	// DryRun records in status the changes the operator would make on GitHub, without making them
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+	// Suspend stops syncing anything to the repository, including when syncs applied to it are reconciled; deletion still cleans up
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
 }
 
 //
@@ -47,6 +95,92 @@ type GithubPropertySyncState struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	GithubPropertyName string `json:"githubPropertyName"`
+	// SyncRef is the name of the GithubActionSecretsSync this property was sourced from
+	// +optional
+	SyncRef string `json:"syncRef,omitempty"`
+	// Environment is the deployment environment the property was synced to, if any
+	// +optional
+	Environment string `json:"environment,omitempty"`
+	// ObservedValueHash is the HMAC-SHA256 of the value last pushed to GitHub, keyed by the operator
+	// +optional
+	ObservedValueHash string `json:"observedValueHash,omitempty"`
+	// SourceRef is where the value last pushed to GitHub comes from
+	// +optional
+	SourceRef *PropertySourceRef `json:"sourceRef,omitempty"`
+	// LastSyncedTime is the last time the value was pushed to GitHub
+	// +optional
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+	// LastAttemptTime is the last time the value was pushed to GitHub, successfully or not
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+	// ObservedGeneration is the generation of the GithubSyncRepo the last attempt was made against
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// FailureCount is the number of attempts failed in a row, reset once the value is pushed
+	// +optional
+	FailureCount int32 `json:"failureCount,omitempty"`
+	// LastError is the error of the last failed attempt, cleared once the value is pushed
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// PlannedActionType defines a change on GitHub
+// +kubebuilder:validation:Enum=Create;Update;Delete
+type PlannedActionType string
+
+const (
+	// PlannedActionCreate pushes a property GitHub does not have yet
+	PlannedActionCreate PlannedActionType = "Create"
+	// PlannedActionUpdate pushes again a property whose value changed
+	PlannedActionUpdate PlannedActionType = "Update"
+	// PlannedActionDelete prunes a property not wanted anymore
+	PlannedActionDelete PlannedActionType = "Delete"
+)
+
+// PlannedAction defines a change a dry run would have made on GitHub
+type PlannedAction struct {
+	// Action is the change that would have been made
+	Action PlannedActionType `json:"action"`
+	// Type is the type of the property: Variable, Secret, DependabotSecret or CodespacesSecret
+	Type string `json:"type"`
+	// GithubPropertyName is the name of the property on GitHub
+	GithubPropertyName string `json:"githubPropertyName"`
+	// Environment is the deployment environment of the property, if any
+	// +optional
+	Environment string `json:"environment,omitempty"`
+	// SyncRef is the name of the GithubActionSecretsSync the property comes from
+	// +optional
+	SyncRef string `json:"syncRef,omitempty"`
+}
+
+// PropertySourceKind defines what a synced value comes from
+// +kubebuilder:validation:Enum=Secret;ConfigMap;Template;Value
+type PropertySourceKind string
+
+const (
+	// PropertySourceSecret is a key of a Kubernetes Secret
+	PropertySourceSecret PropertySourceKind = "Secret"
+	// PropertySourceConfigMap is a key of a Kubernetes ConfigMap
+	PropertySourceConfigMap PropertySourceKind = "ConfigMap"
+	// PropertySourceTemplate is a template rendered from several keys
+	PropertySourceTemplate PropertySourceKind = "Template"
+	// PropertySourceValue is a literal value of the GithubActionSecretsSync
+	PropertySourceValue PropertySourceKind = "Value"
+)
+
+// PropertySourceRef defines where a synced value comes from
+type PropertySourceRef struct {
+	// Kind is what the value comes from
+	Kind PropertySourceKind `json:"kind"`
+	// Name is the name of the Kubernetes Secret or ConfigMap
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Namespace is the namespace of the Kubernetes Secret or ConfigMap
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Key is the key in the Kubernetes Secret or ConfigMap
+	// +optional
+	Key string `json:"key,omitempty"`
 }
 
 // GithubActionSecretsSyncStatus defines the observed state of GithubActionSecretsSync
@@ -55,6 +189,23 @@ type GithubSyncRepoStatus struct {
 	VariablesSyncStates []GithubPropertySyncState `json:"variablesSyncStates,omitempty"`
 	// +optional
 	SecretsSyncStates []GithubPropertySyncState `json:"secretsSyncStates,omitempty"`
+	// +optional
+	DependabotSecretsSyncStates []GithubPropertySyncState `json:"dependabotSecretsSyncStates,omitempty"`
+	// +optional
+	CodespacesSecretsSyncStates []GithubPropertySyncState `json:"codespacesSecretsSyncStates,omitempty"`
+
+	// SyncedCount is the number of properties whose last push succeeded
+	// +optional
+	SyncedCount int32 `json:"syncedCount,omitempty"`
+	// TotalCount is the number of properties tracked on the repository
+	// +optional
+	TotalCount int32 `json:"totalCount,omitempty"`
+	// LastSyncTime is the last time all properties were synced
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// PlannedActions lists the changes dry runs would have made on GitHub, and still would
+	// +optional
+	PlannedActions []PlannedAction `json:"plannedActions,omitempty"`
 
 	// Conditions represent the latest available observations of the sync state
 	// +optional
@@ -69,7 +220,12 @@ type GithubSyncRepoStatus struct {
 // +kubebuilder:resource:scope=Cluster
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Repository",type="string",JSONPath=".spec.repository"
+// +kubebuilder:printcolumn:name="Organization",type="string",JSONPath=".spec.organization.name"
+// +kubebuilder:printcolumn:name="Environment",type="string",JSONPath=".spec.environment"
 // +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="Suspended",type="string",JSONPath=".status.conditions[?(@.type=='Suspended')].status"
+// +kubebuilder:printcolumn:name="Synced Props",type="integer",JSONPath=".status.syncedCount"
+// +kubebuilder:printcolumn:name="Total Props",type="integer",JSONPath=".status.totalCount"
 // +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
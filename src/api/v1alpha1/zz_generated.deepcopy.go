@@ -90,12 +90,40 @@ func (in *GithubActionSecretsSyncSpec) DeepCopyInto(out *GithubActionSecretsSync
 	if in.Secrets != nil {
 		in, out := &in.Secrets, &out.Secrets
 		*out = make([]SecretRef, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Variables != nil {
 		in, out := &in.Variables, &out.Variables
 		*out = make([]VariableRef, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DependabotSecrets != nil {
+		in, out := &in.DependabotSecrets, &out.DependabotSecrets
+		*out = make([]SecretRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CodespacesSecrets != nil {
+		in, out := &in.CodespacesSecrets, &out.CodespacesSecrets
+		*out = make([]SecretRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RepoSelector != nil {
+		in, out := &in.RepoSelector, &out.RepoSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(v1.Duration)
+		**out = **in
 	}
 }
 
@@ -123,6 +151,13 @@ func (in *GithubActionSecretsSyncStatus) DeepCopyInto(out *GithubActionSecretsSy
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]RepositorySyncStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubActionSecretsSyncStatus.
@@ -145,6 +180,19 @@ func (in *GithubPropertySyncState) DeepCopyInto(out *GithubPropertySyncState) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SourceRef != nil {
+		in, out := &in.SourceRef, &out.SourceRef
+		*out = new(PropertySourceRef)
+		**out = **in
+	}
+	if in.LastSyncedTime != nil {
+		in, out := &in.LastSyncedTime, &out.LastSyncedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubPropertySyncState.
@@ -157,6 +205,118 @@ func (in *GithubPropertySyncState) DeepCopy() *GithubPropertySyncState {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubRepoDiscovery) DeepCopyInto(out *GithubRepoDiscovery) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubRepoDiscovery.
+func (in *GithubRepoDiscovery) DeepCopy() *GithubRepoDiscovery {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubRepoDiscovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GithubRepoDiscovery) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubRepoDiscoveryList) DeepCopyInto(out *GithubRepoDiscoveryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GithubRepoDiscovery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubRepoDiscoveryList.
+func (in *GithubRepoDiscoveryList) DeepCopy() *GithubRepoDiscoveryList {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubRepoDiscoveryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GithubRepoDiscoveryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubRepoDiscoverySpec) DeepCopyInto(out *GithubRepoDiscoverySpec) {
+	*out = *in
+	in.Filter.DeepCopyInto(&out.Filter)
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubRepoDiscoverySpec.
+func (in *GithubRepoDiscoverySpec) DeepCopy() *GithubRepoDiscoverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubRepoDiscoverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubRepoDiscoveryStatus) DeepCopyInto(out *GithubRepoDiscoveryStatus) {
+	*out = *in
+	if in.DiscoveredRepositories != nil {
+		in, out := &in.DiscoveredRepositories, &out.DiscoveredRepositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastDiscoveryTime != nil {
+		in, out := &in.LastDiscoveryTime, &out.LastDiscoveryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubRepoDiscoveryStatus.
+func (in *GithubRepoDiscoveryStatus) DeepCopy() *GithubRepoDiscoveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubRepoDiscoveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubSyncRepo) DeepCopyInto(out *GithubSyncRepo) {
 	*out = *in
@@ -219,11 +379,31 @@ func (in *GithubSyncRepoList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubSyncRepoSpec) DeepCopyInto(out *GithubSyncRepoSpec) {
 	*out = *in
+	if in.Organization != nil {
+		in, out := &in.Organization, &out.Organization
+		*out = new(OrganizationTarget)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SecretsSyncRefs != nil {
 		in, out := &in.SecretsSyncRefs, &out.SecretsSyncRefs
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SyncSelector != nil {
+		in, out := &in.SyncSelector, &out.SyncSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Prune != nil {
+		in, out := &in.Prune, &out.Prune
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubSyncRepoSpec.
@@ -253,6 +433,29 @@ func (in *GithubSyncRepoStatus) DeepCopyInto(out *GithubSyncRepoStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DependabotSecretsSyncStates != nil {
+		in, out := &in.DependabotSecretsSyncStates, &out.DependabotSecretsSyncStates
+		*out = make([]GithubPropertySyncState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CodespacesSecretsSyncStates != nil {
+		in, out := &in.CodespacesSecretsSyncStates, &out.CodespacesSecretsSyncStates
+		*out = make([]GithubPropertySyncState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PlannedActions != nil {
+		in, out := &in.PlannedActions, &out.PlannedActions
+		*out = make([]PlannedAction, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -272,6 +475,157 @@ func (in *GithubSyncRepoStatus) DeepCopy() *GithubSyncRepoStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubSyncRepoTemplate) DeepCopyInto(out *GithubSyncRepoTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SecretsSyncRefs != nil {
+		in, out := &in.SecretsSyncRefs, &out.SecretsSyncRefs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SyncSelector != nil {
+		in, out := &in.SyncSelector, &out.SyncSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Prune != nil {
+		in, out := &in.Prune, &out.Prune
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubSyncRepoTemplate.
+func (in *GithubSyncRepoTemplate) DeepCopy() *GithubSyncRepoTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubSyncRepoTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyExpansion) DeepCopyInto(out *KeyExpansion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyExpansion.
+func (in *KeyExpansion) DeepCopy() *KeyExpansion {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyExpansion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrganizationTarget) DeepCopyInto(out *OrganizationTarget) {
+	*out = *in
+	if in.SelectedRepositories != nil {
+		in, out := &in.SelectedRepositories, &out.SelectedRepositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationTarget.
+func (in *OrganizationTarget) DeepCopy() *OrganizationTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(OrganizationTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlannedAction) DeepCopyInto(out *PlannedAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlannedAction.
+func (in *PlannedAction) DeepCopy() *PlannedAction {
+	if in == nil {
+		return nil
+	}
+	out := new(PlannedAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PropertySourceRef) DeepCopyInto(out *PropertySourceRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PropertySourceRef.
+func (in *PropertySourceRef) DeepCopy() *PropertySourceRef {
+	if in == nil {
+		return nil
+	}
+	out := new(PropertySourceRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositoryFilter) DeepCopyInto(out *RepositoryFilter) {
+	*out = *in
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Visibilities != nil {
+		in, out := &in.Visibilities, &out.Visibilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositoryFilter.
+func (in *RepositoryFilter) DeepCopy() *RepositoryFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositoryFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepositorySyncStatus) DeepCopyInto(out *RepositorySyncStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepositorySyncStatus.
+func (in *RepositorySyncStatus) DeepCopy() *RepositorySyncStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RepositorySyncStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRef) DeepCopyInto(out *ResourceRef) {
 	*out = *in
@@ -290,7 +644,17 @@ func (in *ResourceRef) DeepCopy() *ResourceRef {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretRef) DeepCopyInto(out *SecretRef) {
 	*out = *in
-	out.SecretRef = in.SecretRef
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(ResourceRef)
+		**out = **in
+	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(ValueTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	out.KeyExpansion = in.KeyExpansion
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretRef.
@@ -303,10 +667,62 @@ func (in *SecretRef) DeepCopy() *SecretRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateValue) DeepCopyInto(out *TemplateValue) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(ResourceRef)
+		**out = **in
+	}
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(ResourceRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateValue.
+func (in *TemplateValue) DeepCopy() *TemplateValue {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateValue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ValueTemplate) DeepCopyInto(out *ValueTemplate) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]TemplateValue, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ValueTemplate.
+func (in *ValueTemplate) DeepCopy() *ValueTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ValueTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VariableRef) DeepCopyInto(out *VariableRef) {
 	*out = *in
-	out.ConfigMapRef = in.ConfigMapRef
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(ResourceRef)
+		**out = **in
+	}
+	out.KeyExpansion = in.KeyExpansion
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VariableRef.
@@ -22,7 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"sync"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -41,6 +41,9 @@ import (
 
 	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
 	"github.com/qalisa/github-actions-secrets-operator/internal/controller"
+	"github.com/qalisa/github-actions-secrets-operator/internal/metrics"
+	"github.com/qalisa/github-actions-secrets-operator/internal/utils"
+	webhookqalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/internal/webhook/v1alpha1"
 	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
 	// +kubebuilder:scaffold:imports
 )
@@ -70,10 +73,28 @@ func main() {
 	// GitHub App configuration flags
 	var githubAppID_str, githubInstallationID_str string
 	var githubPrivateKeyPath string
+	var hashKeyPath string
+	var insecureUnkeyedHash bool
+	var syncInterval time.Duration
+	var detectDrift bool
+	var dryRun bool
+	var maxConcurrentReconciles int
 
 	flag.StringVar(&githubAppID_str, "github-app-id", "", "GitHub App ID")
 	flag.StringVar(&githubInstallationID_str, "github-installation-id", "", "GitHub App Installation ID")
 	flag.StringVar(&githubPrivateKeyPath, "github-private-key-path", "", "Path to GitHub App private key file")
+	flag.StringVar(&hashKeyPath, "hash-key-path", "",
+		"Path to the key values recorded in status are hashed with. Changing it makes every value pushed again.")
+	flag.BoolVar(&insecureUnkeyedHash, "insecure-unkeyed-hash", false,
+		"If set, values recorded in status are hashed without key when no hash key is provided. Only meant for local development.")
+	flag.DurationVar(&syncInterval, "sync-interval", 30*time.Minute,
+		"How often resources not defining their own syncInterval are re-applied to GitHub, with jitter. 0 disables periodic resync.")
+	flag.BoolVar(&detectDrift, "detect-drift", true,
+		"If set, synced properties are compared with what lives on GitHub and restored if changed by hand.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"If set, changes are only planned in GithubSyncRepo status and Events, never made on GitHub.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 4,
+		"How many resources of each kind are reconciled in parallel. Writes to a same GitHub repository are always serialized.")
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -157,6 +178,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Read the key hashing values recorded in status
+	if hashKeyPath == "" {
+		hashKeyPath = os.Getenv("HASH_KEY_PATH")
+	}
+	if hashKeyPath != "" {
+		hashKey, err := os.ReadFile(hashKeyPath)
+		if err != nil {
+			setupLog.Error(err, "failed to read hash key")
+			os.Exit(1)
+		}
+		utils.SetValueHashKey(hashKey)
+	} else if insecureUnkeyedHash {
+		setupLog.Info("WARNING: no hash key provided, values recorded in status are hashed without key and may be guessed from it")
+	} else {
+		setupLog.Error(nil, "Hash key path is required, unless --insecure-unkeyed-hash is set")
+		os.Exit(1)
+	}
+
 	//
 	//
 	//
@@ -166,6 +205,7 @@ func main() {
 		AppID:          githubAppID,
 		InstallationID: githubInstallationID,
 		PrivateKey:     privateKey,
+		Observer:       metrics.GithubObserver{},
 	})
 	if err != nil {
 		setupLog.Error(err, "failed to create GitHub client")
@@ -249,28 +289,59 @@ func main() {
 		os.Exit(1)
 	}
 
-	mutex := sync.RWMutex{}
+	repoLocks := utils.NewRepoLocks()
 
 	if err = (&controller.GithubActionSecretsSyncReconciler{
-		Client:       mgr.GetClient(),
-		Scheme:       mgr.GetScheme(),
-		GitHubClient: githubClient,
-		RWMutex:      &mutex,
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		GitHubClient:            githubClient,
+		SyncInterval:            syncInterval,
+		DetectDrift:             detectDrift,
+		DryRun:                  dryRun,
+		RepoLocks:               repoLocks,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Recorder:                mgr.GetEventRecorderFor("github-actions-secrets-operator"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GithubActionSecretsSync")
 		os.Exit(1)
 	}
 
 	if err = (&controller.GithubSyncRepoReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		GitHubClient:            githubClient,
+		SyncInterval:            syncInterval,
+		DetectDrift:             detectDrift,
+		DryRun:                  dryRun,
+		RepoLocks:               repoLocks,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		Recorder:                mgr.GetEventRecorderFor("github-actions-secrets-operator"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GithubSyncRepo")
+		os.Exit(1)
+	}
+
+	if err = (&controller.GithubRepoDiscoveryReconciler{
 		Client:       mgr.GetClient(),
 		Scheme:       mgr.GetScheme(),
 		GitHubClient: githubClient,
-		RWMutex:      &mutex,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "GithubSyncRepo")
+		setupLog.Error(err, "unable to create controller", "controller", "GithubRepoDiscovery")
 		os.Exit(1)
 	}
 
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookqalisav1alpha1.SetupGithubActionSecretsSyncWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GithubActionSecretsSync")
+			os.Exit(1)
+		}
+		if err = webhookqalisav1alpha1.SetupGithubSyncRepoWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GithubSyncRepo")
+			os.Exit(1)
+		}
+	}
+
 	if metricsCertWatcher != nil {
 		setupLog.Info("Adding metrics certificate watcher to manager")
 		if err := mgr.Add(metricsCertWatcher); err != nil {
@@ -4,13 +4,25 @@ package controller
 
 import (
 	"context"
-	"sync"
+	goerrors "errors"
+	"fmt"
+	"time"
 
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
 	"github.com/qalisa/github-actions-secrets-operator/internal/utils"
@@ -20,21 +32,32 @@ import (
 type GithubActionSecretsSyncReconciler struct {
 	client.Client
 	*runtime.Scheme
-	*sync.RWMutex
 	GitHubClient github.Client
+	// Shared with the other reconcilers writing to Github
+	RepoLocks *utils.RepoLocks
+	// How many resources are reconciled in parallel
+	MaxConcurrentReconciles int
+	// Emits Events telling about failures and successful syncs
+	Recorder record.EventRecorder
+	// Resync interval of resources not defining theirs, 0 disabling periodic resync
+	SyncInterval time.Duration
+	// Whether properties are compared with what lives on Github, to restore those changed by hand
+	DetectDrift bool
+	// Whether changes are only planned in status, never made on Github
+	DryRun bool
 }
 
 // +kubebuilder:rbac:groups=qalisa.github.io,resources=githubactionsecretssyncs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=qalisa.github.io,resources=githubactionsecretssyncs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=qalisa.github.io,resources=githubactionsecretssyncs/finalizers,verbs=update
 // +kubebuilder:rbac:groups=qalisa.github.io,resources=githubsyncrepoes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=qalisa.github.io,resources=githubsyncrepoes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *GithubActionSecretsSyncReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
-	r.RWMutex.Lock()
-	defer r.RWMutex.Unlock()
 
 	// defer func() {
 	// 	if r := recover(); r != nil {
@@ -49,8 +72,10 @@ func (r *GithubActionSecretsSyncReconciler) Reconcile(ctx context.Context, req c
 	var syncErr error
 	var result ctrl.Result
 	var dataBySync utils.SecVarsBySync
-	var allRepoConfigs qalisav1alpha1.GithubSyncRepoList
+	var appliedRepoConfigs []qalisav1alpha1.GithubSyncRepo
 	var toApplyTo []*qalisav1alpha1.GithubSyncRepo
+	var reports []utils.RepoSyncReport
+	var err error
 
 	//
 	// Try to get instance of CRD
@@ -58,24 +83,47 @@ func (r *GithubActionSecretsSyncReconciler) Reconcile(ctx context.Context, req c
 
 	instance := &qalisav1alpha1.GithubActionSecretsSync{}
 	if err := r.Get(ctx, req.NamespacedName, instance); err != nil {
-		// Do not exist anymore ?
+		// Do not exist anymore ? Cleanup already happened while finalizing
 		if errors.IsNotFound(err) {
-			//
-			// TODO: HANDLE DELETION of resource
-			//
-			goto doRegisterStatus
+			return ctrl.Result{}, nil
 		}
 
 		logger.Error(err, "Unexpected fatal error while fetching current GithubActionSecretsSync; rescheduling reconciliation.")
 		return ctrl.Result{}, err
 	}
 
+	//
+	// Handle deletion, or make sure we will get to handle it
+	//
+
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, logger, instance)
+	}
+
+	if controllerutil.AddFinalizer(instance, utils.GithubCleanupFinalizer) {
+		if err := r.Update(ctx, instance); err != nil {
+			logger.Error(err, "Unable to add finalizer to GithubActionSecretsSync; rescheduling reconciliation.")
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// Nothing is pushed nor pruned while suspended
+	//
+
+	utils.DefineSuspendedStatus(instance, &instance.Status.Conditions, instance.Spec.Suspend)
+	if instance.Spec.Suspend {
+		logger.Info("GithubActionSecretsSync suspended, skipping reconciliation")
+		goto doRegisterStatus
+	}
+
 	//
 	// Fill sync buffer
 	//
 
 	if err := utils.FillSyncBuffer(ctx, r.Client, instance, &dataBySync); err != nil {
-		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", err.Error())
+		utils.DefineSyncPreparationFailure(instance, err)
+		utils.RecordEvent(r.Recorder, instance, corev1.EventTypeWarning, utils.EventReasonMissingSource, "Unable to prepare secrets and variables: %s", err)
 		logger.Error(err, "Unable to prepare secrets and variables")
 		goto doRegisterStatus
 	}
@@ -84,24 +132,38 @@ func (r *GithubActionSecretsSyncReconciler) Reconcile(ctx context.Context, req c
 	// Filter from all repo configs which that are concerned
 	//
 
-	// List all resources of the specified type
-	if err := r.List(ctx, &allRepoConfigs, &client.ListOptions{}); err != nil {
-		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", err.Error())
+	// Either referencing this sync by name, or matched through selectors
+	appliedRepoConfigs, err = utils.ListReposAppliedWith(ctx, r.Client, logger, r.Recorder, instance)
+	if err != nil {
+		utils.DefineSyncPreparationFailure(instance, err)
 		logger.Error(err, "Could not get GithubSyncRepo resources from cluster")
 		goto doRegisterStatus
 	}
 
-	for _, repo := range allRepoConfigs.Items {
-		if utils.Contains(repo.Spec.SecretsSyncRefs, instance.Name) {
-			toApplyTo = append(toApplyTo, &repo)
-		}
+	for i := range appliedRepoConfigs {
+		toApplyTo = append(toApplyTo, &appliedRepoConfigs[i])
 	}
 
 	//
 	//
 	//
 
-	result, syncErr = utils.SynchronizeToGithub(ctx, r.Client, logger, r.GitHubClient, toApplyTo, dataBySync)
+	result, reports, syncErr = utils.SynchronizeToGithub(ctx, r.Client, logger, r.GitHubClient, toApplyTo, dataBySync, utils.SyncOptions{
+		DetectDrift: r.DetectDrift,
+		Locks:       r.RepoLocks,
+		Recorder:    r.Recorder,
+		DryRun:      r.DryRun || instance.Spec.DryRun,
+	})
+	utils.DefineFanOutStatus(instance, toApplyTo, reports)
+
+	if syncErr == nil && len(toApplyTo) > 0 && utils.AllReposSynced(toApplyTo) {
+		utils.RecordEvent(r.Recorder, instance, corev1.EventTypeNormal, utils.EventReasonSynced, "All properties synced to %d repositories", len(toApplyTo))
+	}
+
+	// Github does not notify us of changes made by hand, apply again later
+	if syncErr == nil && result.RequeueAfter == 0 {
+		result.RequeueAfter = utils.ResyncAfter(instance.Spec.SyncInterval, r.SyncInterval)
+	}
 
 	//
 	//
@@ -118,9 +180,172 @@ doRegisterStatus:
 	return result, syncErr
 }
 
+// Cleans up from every repository what this sync pushed, according to the deletion policy, then lets the resource go
+func (r *GithubActionSecretsSyncReconciler) finalize(ctx context.Context, logger logr.Logger, instance *qalisav1alpha1.GithubActionSecretsSync) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, utils.GithubCleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	var allRepoConfigs qalisav1alpha1.GithubSyncRepoList
+	if err := r.List(ctx, &allRepoConfigs, &client.ListOptions{}); err != nil {
+		logger.Error(err, "Could not get GithubSyncRepo resources from cluster")
+		return ctrl.Result{}, err
+	}
+
+	//
+	var cleanupErrs []error
+	// a dry run never touches Github, not even to clean up
+	orphan := !utils.ShouldDeleteFromGithub(instance.Spec.DeletionPolicy) || r.DryRun || instance.Spec.DryRun
+	for i := range allRepoConfigs.Items {
+		repo := &allRepoConfigs.Items[i]
+		if !utils.HasGHPropertiesFrom(repo, instance.Name) {
+			continue
+		}
+
+		// suspended repositories are left untouched on Github too, their properties are orphaned
+		unlock := r.RepoLocks.Lock(repo)
+		if err := utils.CleanupFromGithub(ctx, logger, r.GitHubClient, repo, instance.Name, orphan || repo.Spec.DryRun || repo.Spec.Suspend); err != nil {
+			cleanupErrs = append(cleanupErrs, fmt.Errorf("GithubSyncRepo '%s': %w", repo.Name, err))
+		}
+
+		//
+		err := r.Status().Update(ctx, repo)
+		unlock()
+		if err != nil {
+			logger.Error(err, "Unexpected fatal error while saving status for GithubSyncRepo; rescheduling reconciliation.", "repo", repo.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	if err := goerrors.Join(cleanupErrs...); err != nil {
+		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", fmt.Sprintf("Cleanup before deletion failed: %s", err))
+		logger.Error(err, "Unable to clean up GithubActionSecretsSync properties from Github; rescheduling reconciliation.")
+		if err := r.Status().Update(ctx, instance); err != nil {
+			logger.Error(err, "Unexpected fatal error while saving status for current GithubActionSecretsSync; rescheduling reconciliation.")
+		}
+		if result, limited := utils.RequeueWhenRateLimited(err); limited {
+			return result, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	//
+	controllerutil.RemoveFinalizer(instance, utils.GithubCleanupFinalizer)
+	if err := r.Update(ctx, instance); err != nil {
+		logger.Error(err, "Unable to remove finalizer from GithubActionSecretsSync; rescheduling reconciliation.")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+//
+//
+//
+
+const (
+	secretRefsIndexFieldName    = "spec.secrets.secretRef"
+	configMapRefsIndexFieldName = "spec.variables.configMapRef"
+)
+
+// Lists the GithubActionSecretsSync consuming the Secret or ConfigMap obj, depending on the index used
+func listSyncsReferencing(ctx context.Context, c client.Client, indexFieldName string, obj client.Object) ([]qalisav1alpha1.GithubActionSecretsSync, error) {
+	var syncs qalisav1alpha1.GithubActionSecretsSyncList
+	ref := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}.String()
+	if err := c.List(ctx, &syncs, client.MatchingFields{indexFieldName: ref}); err != nil {
+		return nil, err
+	}
+	return syncs.Items, nil
+}
+
+// Produces a map function enqueueing the GithubActionSecretsSync consuming a changed Secret or ConfigMap.
+// Only syncs are enqueued: they push to every repository they apply to.
+func (r *GithubActionSecretsSyncReconciler) enqueueSyncsReferencing(indexFieldName string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		syncs, err := listSyncsReferencing(ctx, r.Client, indexFieldName, obj)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "Could not get GithubActionSecretsSync resources referencing changed object", "object", client.ObjectKeyFromObject(obj))
+			return nil
+		}
+
+		//
+		requests := make([]reconcile.Request, 0, len(syncs))
+		for _, sync := range syncs {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&sync)})
+		}
+		return requests
+	}
+}
+
+// Objects the values of a template come from, as "namespace/name", for one kind picked by refOf
+func templateRefs(valueTemplate *qalisav1alpha1.ValueTemplate, refOf func(qalisav1alpha1.TemplateValue) *qalisav1alpha1.ResourceRef) []string {
+	if valueTemplate == nil {
+		return nil
+	}
+
+	//
+	refs := []string{}
+	for _, value := range valueTemplate.Values {
+		if ref := refOf(value); ref != nil {
+			refs = append(refs, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}.String())
+		}
+	}
+	return refs
+}
+
 func (r *GithubActionSecretsSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Index referenced Secrets and ConfigMaps, as "namespace/name"
+	if err := mgr.GetFieldIndexer().IndexField(
+		context.Background(),
+		&qalisav1alpha1.GithubActionSecretsSync{},
+		secretRefsIndexFieldName,
+		func(obj client.Object) []string {
+			refs := []string{}
+			spec := obj.(*qalisav1alpha1.GithubActionSecretsSync).Spec
+			for _, secretRefs := range [][]qalisav1alpha1.SecretRef{spec.Secrets, spec.DependabotSecrets, spec.CodespacesSecrets} {
+				for _, secretRef := range secretRefs {
+					if secretRef.SecretRef != nil {
+						refs = append(refs, types.NamespacedName{Namespace: secretRef.SecretRef.Namespace, Name: secretRef.SecretRef.Name}.String())
+					}
+					refs = append(refs, templateRefs(secretRef.Template, func(value qalisav1alpha1.TemplateValue) *qalisav1alpha1.ResourceRef { return value.SecretRef })...)
+				}
+			}
+			return refs
+		},
+	); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(
+		context.Background(),
+		&qalisav1alpha1.GithubActionSecretsSync{},
+		configMapRefsIndexFieldName,
+		func(obj client.Object) []string {
+			refs := []string{}
+			spec := obj.(*qalisav1alpha1.GithubActionSecretsSync).Spec
+			for _, configMapRef := range spec.Variables {
+				if configMapRef.ConfigMapRef != nil {
+					refs = append(refs, types.NamespacedName{Namespace: configMapRef.ConfigMapRef.Namespace, Name: configMapRef.ConfigMapRef.Name}.String())
+				}
+			}
+			for _, secretRefs := range [][]qalisav1alpha1.SecretRef{spec.Secrets, spec.DependabotSecrets, spec.CodespacesSecrets} {
+				for _, secretRef := range secretRefs {
+					refs = append(refs, templateRefs(secretRef.Template, func(value qalisav1alpha1.TemplateValue) *qalisav1alpha1.ResourceRef { return value.ConfigMapRef })...)
+				}
+			}
+			return refs
+		},
+	); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&qalisav1alpha1.GithubActionSecretsSync{}).
+		// its own status updates must not trigger a sync run again, resync interval does
+		For(&qalisav1alpha1.GithubActionSecretsSync{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.enqueueSyncsReferencing(secretRefsIndexFieldName))).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.enqueueSyncsReferencing(configMapRefsIndexFieldName))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Named("githubactionsecretssync").
 		Complete(r)
 }
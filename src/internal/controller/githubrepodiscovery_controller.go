@@ -0,0 +1,212 @@
+// repo_discovery_controller.go
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"github.com/qalisa/github-actions-secrets-operator/internal/utils"
+	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
+)
+
+// Used when no interval is defined on the resource
+const defaultDiscoveryInterval = 10 * time.Minute
+
+type GithubRepoDiscoveryReconciler struct {
+	client.Client
+	*runtime.Scheme
+	GitHubClient github.Client
+}
+
+// +kubebuilder:rbac:groups=qalisa.github.io,resources=githubrepodiscoveries,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=qalisa.github.io,resources=githubrepodiscoveries/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=qalisa.github.io,resources=githubrepodiscoveries/finalizers,verbs=update
+// +kubebuilder:rbac:groups=qalisa.github.io,resources=githubsyncrepoes,verbs=get;list;watch;create;update;patch;delete
+
+func (r *GithubRepoDiscoveryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	//
+	//
+	//
+
+	var discovered []github.Repository
+	var ownedRepoConfigs qalisav1alpha1.GithubSyncRepoList
+	var syncErrs []error
+	desiredNames := map[string]string{}
+	var applied, failed []string
+	interval := defaultDiscoveryInterval
+
+	//
+	// Try to get instance of CRD
+	//
+
+	instance := &qalisav1alpha1.GithubRepoDiscovery{}
+	if err := r.Get(ctx, req.NamespacedName, instance); err != nil {
+		// Do not exist anymore ? Owned GithubSyncRepo are garbage collected
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		logger.Error(err, "Unexpected fatal error while fetching current GithubRepoDiscovery; rescheduling reconciliation.")
+		return ctrl.Result{}, err
+	}
+
+	if instance.Spec.Interval != nil && instance.Spec.Interval.Duration > 0 {
+		interval = instance.Spec.Interval.Duration
+	}
+
+	//
+	// Discover repositories from Github API
+	//
+
+	repositories, err := r.GitHubClient.ListInstallationRepositories(ctx)
+	if err != nil {
+		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", err.Error())
+		logger.Error(err, "Unable to list repositories of the installation")
+		if result, limited := utils.RequeueWhenRateLimited(err); limited {
+			interval = result.RequeueAfter
+		}
+		goto doRegisterStatus
+	}
+
+	discovered, err = utils.FilterDiscoveredRepositories(instance.Spec.Filter, repositories)
+	if err != nil {
+		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", err.Error())
+		logger.Error(err, "Unable to filter repositories of the installation")
+		goto doRegisterStatus
+	}
+
+	//
+	// Create or update a GithubSyncRepo per discovered repository
+	//
+
+	for _, repo := range discovered {
+		name := utils.DiscoveredRepoName(instance.Name, repo.FullName)
+		desiredNames[name] = repo.FullName
+
+		//
+		if err := r.applyTemplate(ctx, instance, name, repo.FullName); err != nil {
+			logger.Error(err, "Unable to create or update GithubSyncRepo for discovered repository", "repository", repo.FullName)
+			syncErrs = append(syncErrs, err)
+			failed = append(failed, repo.FullName)
+			continue
+		}
+		applied = append(applied, repo.FullName)
+	}
+	utils.DefineAppliedStatus(instance, &instance.Status.Conditions, failed)
+
+	//
+	// Remove GithubSyncRepo of repositories not discovered anymore
+	//
+
+	if err := r.List(ctx, &ownedRepoConfigs, client.MatchingLabels{utils.DiscoveryLabel: string(instance.UID)}); err != nil {
+		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", err.Error())
+		logger.Error(err, "Could not get GithubSyncRepo resources from cluster")
+		goto doRegisterStatus
+	}
+
+	for _, repoConfig := range ownedRepoConfigs.Items {
+		if _, desired := desiredNames[repoConfig.Name]; desired || !metav1.IsControlledBy(&repoConfig, instance) {
+			continue
+		}
+
+		logger.Info("Repository not discovered anymore, removing its GithubSyncRepo", "repo", repoConfig.Name)
+		if err := r.Delete(ctx, &repoConfig); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Unable to delete GithubSyncRepo of repository not discovered anymore", "repo", repoConfig.Name)
+			syncErrs = append(syncErrs, err)
+		}
+	}
+
+	//
+	//
+	//
+
+	// only repositories having their GithubSyncRepo, failed ones are told by the Applied condition
+	slices.Sort(applied)
+	instance.Status.DiscoveredRepositories = applied
+	instance.Status.LastDiscoveryTime = &metav1.Time{Time: time.Now()}
+	if len(syncErrs) > 0 {
+		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", fmt.Sprintf("Discovered %d repositories, with errors: %v", len(desiredNames), syncErrs))
+	} else {
+		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "True", fmt.Sprintf("Discovered %d repositories", len(desiredNames)))
+	}
+
+	//
+	//
+	//
+
+doRegisterStatus:
+	// now, try to update this instance's status
+	if err := r.Client.Status().Update(ctx, instance); err != nil {
+		logger.Error(err, "Unexpected fatal error while saving status for current GithubRepoDiscovery; rescheduling reconciliation.")
+		return ctrl.Result{}, err
+	}
+
+	// Github does not notify us, look again later
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// Creates or updates the GithubSyncRepo of a discovered repository from the template of the discovery
+func (r *GithubRepoDiscoveryReconciler) applyTemplate(ctx context.Context, instance *qalisav1alpha1.GithubRepoDiscovery, name string, repoFullName string) error {
+	repoConfig := &qalisav1alpha1.GithubSyncRepo{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	template := instance.Spec.Template
+
+	//
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, repoConfig, func() error {
+		// never take over a GithubSyncRepo we did not create
+		if !repoConfig.CreationTimestamp.IsZero() && !metav1.IsControlledBy(repoConfig, instance) {
+			return fmt.Errorf("GithubSyncRepo '%s' already exists and is not managed by GithubRepoDiscovery '%s'", name, instance.Name)
+		}
+
+		//
+		// merged, keeping what others set on it
+		if repoConfig.Labels == nil {
+			repoConfig.Labels = map[string]string{}
+		}
+		maps.Copy(repoConfig.Labels, template.Labels)
+		repoConfig.Labels[utils.DiscoveryLabel] = string(instance.UID)
+		if repoConfig.Annotations == nil {
+			repoConfig.Annotations = map[string]string{}
+		}
+		repoConfig.Annotations[utils.DiscoveredRepositoryAnnotation] = repoFullName
+
+		//
+		repoConfig.Spec.Repository = repoFullName
+		repoConfig.Spec.SecretsSyncRefs = template.SecretsSyncRefs
+		repoConfig.Spec.SyncSelector = template.SyncSelector
+		repoConfig.Spec.Prune = template.Prune
+		repoConfig.Spec.DeletionPolicy = template.DeletionPolicy
+		repoConfig.Spec.SyncInterval = template.SyncInterval
+
+		//
+		return controllerutil.SetControllerReference(instance, repoConfig, r.Scheme)
+	})
+
+	return err
+}
+
+func (r *GithubRepoDiscoveryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// its own status updates must not trigger discovery again, interval does
+		For(&qalisav1alpha1.GithubRepoDiscovery{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// status updates of owned repos are not of our concern
+		Owns(&qalisav1alpha1.GithubSyncRepo{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("githubrepodiscovery").
+		Complete(r)
+}
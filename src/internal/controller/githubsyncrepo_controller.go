@@ -5,15 +5,25 @@ package controller
 import (
 	"context"
 	"fmt"
-	"sync"
+	"time"
 
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"github.com/qalisa/github-actions-secrets-operator/internal/metrics"
 	"github.com/qalisa/github-actions-secrets-operator/internal/utils"
 	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
 )
@@ -21,8 +31,19 @@ import (
 type GithubSyncRepoReconciler struct {
 	client.Client
 	*runtime.Scheme
-	*sync.RWMutex
 	GitHubClient github.Client
+	// Shared with the other reconcilers writing to Github
+	RepoLocks *utils.RepoLocks
+	// How many resources are reconciled in parallel
+	MaxConcurrentReconciles int
+	// Emits Events telling about failures and successful syncs
+	Recorder record.EventRecorder
+	// Resync interval of resources not defining theirs, 0 disabling periodic resync
+	SyncInterval time.Duration
+	// Whether properties are compared with what lives on Github, to restore those changed by hand
+	DetectDrift bool
+	// Whether changes are only planned in status, never made on Github
+	DryRun bool
 }
 
 // +kubebuilder:rbac:groups=qalisa.github.io,resources=githubsyncrepoes,verbs=get;list;watch;create;update;patch;delete
@@ -31,11 +52,10 @@ type GithubSyncRepoReconciler struct {
 // +kubebuilder:rbac:groups=qalisa.github.io,resources=githubactionsecretssyncs,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *GithubSyncRepoReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
-	r.RWMutex.Lock()
-	defer r.RWMutex.Unlock()
 
 	// defer func() {
 	// 	if r := recover(); r != nil {
@@ -54,6 +74,8 @@ func (r *GithubSyncRepoReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	var dataBySync utils.SecVarsBySync
 	concernedSyncConfigs := []qalisav1alpha1.GithubActionSecretsSync{}
 	var tempSyncConfigs qalisav1alpha1.GithubActionSecretsSyncList
+	var selectedSyncConfigs []qalisav1alpha1.GithubActionSecretsSync
+	var err error
 	reachedSync := false
 
 	//
@@ -61,18 +83,40 @@ func (r *GithubSyncRepoReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	//
 
 	if err := r.Get(ctx, req.NamespacedName, instance); err != nil {
-		// Do not exist anymore ?
+		// Do not exist anymore ? Cleanup already happened while finalizing
 		if errors.IsNotFound(err) {
-			//
-			// TODO: HANDLE DELETION of resource
-			//
-			goto doRegisterStatus
+			return ctrl.Result{}, nil
 		}
 
 		logger.Error(err, "Unexpected fatal error while fetching current GithubSyncRepo; rescheduling reconciliation.")
 		return ctrl.Result{}, err
 	}
 
+	//
+	// Handle deletion, or make sure we will get to handle it
+	//
+
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, logger, instance)
+	}
+
+	if controllerutil.AddFinalizer(instance, utils.GithubCleanupFinalizer) {
+		if err := r.Update(ctx, instance); err != nil {
+			logger.Error(err, "Unable to add finalizer to GithubSyncRepo; rescheduling reconciliation.")
+			return ctrl.Result{}, err
+		}
+	}
+
+	//
+	// Nothing is pushed nor pruned while suspended
+	//
+
+	if instance.Spec.Suspend {
+		utils.DefineSuspendedStatus(instance, &instance.Status.Conditions, true)
+		logger.Info("GithubSyncRepo suspended, skipping reconciliation")
+		goto doRegisterStatus
+	}
+
 	//
 	// test parsing of repo name
 	//
@@ -101,14 +145,39 @@ func (r *GithubSyncRepoReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		if found != 1 {
 			err := fmt.Errorf("failed to find referenced GithubActionSecretsSync '%s' within cluster (found %d)", name, found)
 			utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", err.Error())
+			utils.RecordEvent(r.Recorder, instance, corev1.EventTypeWarning, utils.EventReasonSyncNotFound, "Referenced GithubActionSecretsSync '%s' not found", name)
 			logger.Error(err, "Unable to find GithubActionSecretsSync referenced by GithubRepo")
 			goto doRegisterStatus
 		}
 
+		// being deleted, its own finalizer takes care of what it pushed; suspended, it is left as is
+		if !tempSyncConfigs.Items[0].DeletionTimestamp.IsZero() || tempSyncConfigs.Items[0].Spec.Suspend {
+			continue
+		}
+
 		// append first to concerned
 		concernedSyncConfigs = append(concernedSyncConfigs, tempSyncConfigs.Items[0])
 	}
 
+	//
+	// Find Syncs applied through selectors
+	//
+
+	selectedSyncConfigs, err = utils.ListSyncsSelectedFor(ctx, r.Client, logger, r.Recorder, instance)
+	if err != nil {
+		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", err.Error())
+		logger.Error(err, "Could not resolve GithubActionSecretsSync resources selected for GithubRepo")
+		goto doRegisterStatus
+	}
+
+	for _, sync := range selectedSyncConfigs {
+		// being deleted, its own finalizer takes care of what it pushed; suspended, it is left as is
+		if !sync.DeletionTimestamp.IsZero() || sync.Spec.Suspend {
+			continue
+		}
+		concernedSyncConfigs = append(concernedSyncConfigs, sync)
+	}
+
 	//
 	// Fill sync buffer
 	//
@@ -116,6 +185,7 @@ func (r *GithubSyncRepoReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	for _, sync := range concernedSyncConfigs {
 		if err := utils.FillSyncBuffer(ctx, r.Client, &sync, &dataBySync); err != nil {
 			utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", err.Error())
+			utils.RecordEvent(r.Recorder, instance, corev1.EventTypeWarning, utils.EventReasonMissingSource, "Unable to prepare secrets and variables of GithubActionSecretsSync '%s': %s", sync.Name, err)
 			logger.Error(err, "Unable to prepare secrets and variables")
 			goto doRegisterStatus
 		}
@@ -125,9 +195,19 @@ func (r *GithubSyncRepoReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	//
 	//
 
-	result, syncErr = utils.SynchronizeToGithub(ctx, r.Client, logger, r.GitHubClient, toApplyTo, dataBySync)
+	result, _, syncErr = utils.SynchronizeToGithub(ctx, r.Client, logger, r.GitHubClient, toApplyTo, dataBySync, utils.SyncOptions{
+		DetectDrift: r.DetectDrift,
+		Locks:       r.RepoLocks,
+		Recorder:    r.Recorder,
+		DryRun:      r.DryRun,
+	})
 	reachedSync = true
 
+	// Github does not notify us of changes made by hand, apply again later
+	if syncErr == nil && result.RequeueAfter == 0 {
+		result.RequeueAfter = utils.ResyncAfter(instance.Spec.SyncInterval, r.SyncInterval)
+	}
+
 	//
 	//
 	//
@@ -145,12 +225,74 @@ doRegisterStatus:
 	return result, syncErr
 }
 
+// Cleans up what was pushed to Github according to the deletion policy, then lets the resource go
+func (r *GithubSyncRepoReconciler) finalize(ctx context.Context, logger logr.Logger, instance *qalisav1alpha1.GithubSyncRepo) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, utils.GithubCleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	//
+	// a dry run never touches Github, not even to clean up
+	orphan := !utils.ShouldDeleteFromGithub(instance.Spec.DeletionPolicy) || r.DryRun || instance.Spec.DryRun
+	unlock := r.RepoLocks.Lock(instance)
+	err := utils.CleanupFromGithub(ctx, logger, r.GitHubClient, instance, "", orphan)
+	unlock()
+	if err != nil {
+		utils.SetSyncedStatusCondition(instance, &instance.Status.Conditions, "False", fmt.Sprintf("Cleanup before deletion failed: %s", err))
+		logger.Error(err, "Unable to clean up GithubSyncRepo properties from Github; rescheduling reconciliation.")
+		if err := r.Status().Update(ctx, instance); err != nil {
+			logger.Error(err, "Unexpected fatal error while saving status for current GithubSyncRepo; rescheduling reconciliation.")
+		}
+		if result, limited := utils.RequeueWhenRateLimited(err); limited {
+			return result, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	//
+	controllerutil.RemoveFinalizer(instance, utils.GithubCleanupFinalizer)
+	if err := r.Update(ctx, instance); err != nil {
+		logger.Error(err, "Unable to remove finalizer from GithubSyncRepo; rescheduling reconciliation.")
+		return ctrl.Result{}, err
+	}
+	metrics.ForgetRepo(instance.Name)
+
+	return ctrl.Result{}, nil
+}
+
 //
 //
 //
 
 const indexFieldName = "metadata.name"
 
+// Enqueues the GithubSyncRepo a changed GithubActionSecretsSync is applied to, and those still holding properties it pushed,
+// so that repositories it stopped applying to get pruned
+func (r *GithubSyncRepoReconciler) enqueueReposOfSync(ctx context.Context, obj client.Object) []reconcile.Request {
+	logger := log.FromContext(ctx)
+	sync := obj.(*qalisav1alpha1.GithubActionSecretsSync)
+
+	//
+	var allRepos qalisav1alpha1.GithubSyncRepoList
+	if err := r.List(ctx, &allRepos); err != nil {
+		logger.Error(err, "Could not get GithubSyncRepo resources from cluster")
+		return nil
+	}
+
+	//
+	requests := []reconcile.Request{}
+	for _, repo := range allRepos.Items {
+		applied, err := utils.IsSyncAppliedTo(sync, &repo)
+		if err != nil {
+			logger.Error(err, "Could not tell if GithubActionSecretsSync is applied to GithubSyncRepo", "sync", sync.Name, "repo", repo.Name)
+		}
+		if applied || utils.HasGHPropertiesFrom(&repo, sync.Name) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&repo)})
+		}
+	}
+	return requests
+}
+
 func (r *GithubSyncRepoReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Set up the index
 	if err := mgr.GetFieldIndexer().IndexField(
@@ -164,8 +306,15 @@ func (r *GithubSyncRepoReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		panic("issue with index definition")
 	}
 
+	// Secrets and ConfigMaps changes reach repositories through the GithubActionSecretsSync consuming them, which fans out
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&qalisav1alpha1.GithubSyncRepo{}).
+		Watches(
+			&qalisav1alpha1.GithubActionSecretsSync{},
+			handler.EnqueueRequestsFromMapFunc(r.enqueueReposOfSync),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{})),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Named("githubsyncrepo").
 		Complete(r)
 }
@@ -0,0 +1,81 @@
+// Package metrics exposes the operator custom metrics, served by the manager metrics endpoint
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "github_actions_secrets_operator"
+
+var (
+	// PropertySyncs counts sync outcomes of properties, per GithubSyncRepo and type.
+	// Outcome is one of "synced", "skipped", "failed", "pruned" or "drifted".
+	PropertySyncs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "property_syncs_total",
+			Help:      "Sync outcomes of secrets and variables, per GithubSyncRepo and type.",
+		},
+		[]string{"repo", "type", "outcome"},
+	)
+
+	// LastSuccessfulSync is the time the GithubSyncRepo last had all its properties synced
+	LastSuccessfulSync = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_successful_sync_timestamp_seconds",
+			Help:      "Unix time at which all properties of the GithubSyncRepo were last synced successfully.",
+		},
+		[]string{"repo"},
+	)
+
+	// GithubRequestDuration observes GitHub API requests, per method and status code
+	GithubRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "github_request_duration_seconds",
+			Help:      "Latency of GitHub API requests, per method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "code"},
+	)
+
+	// GithubRateLimitRemaining is the requests GitHub API still accepts before its rate limit resets, per resource
+	GithubRateLimitRemaining = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "github_rate_limit_remaining",
+			Help:      "Requests GitHub API still accepts before its rate limit resets, per rate limit resource.",
+		},
+		[]string{"resource"},
+	)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		PropertySyncs,
+		LastSuccessfulSync,
+		GithubRequestDuration,
+		GithubRateLimitRemaining,
+	)
+}
+
+// ForgetRepo drops the series of a deleted GithubSyncRepo
+func ForgetRepo(repo string) {
+	PropertySyncs.DeletePartialMatch(prometheus.Labels{"repo": repo})
+	LastSuccessfulSync.DeleteLabelValues(repo)
+}
+
+// GithubObserver exposes GitHub API requests made by the client as metrics
+type GithubObserver struct{}
+
+func (GithubObserver) ObserveRequest(method, code string, duration time.Duration) {
+	GithubRequestDuration.WithLabelValues(method, code).Observe(duration.Seconds())
+}
+
+func (GithubObserver) ObserveRateLimitRemaining(resource string, remaining float64) {
+	GithubRateLimitRemaining.WithLabelValues(resource).Set(remaining)
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Syncs defining each property of a repository, the winning one first
+type ghPropertyOwners map[GithubActionSecVarType]map[string][]string
+
+// Whether the sync is the one whose value is pushed for this property
+func (o ghPropertyOwners) isOwnedBy(secVarType GithubActionSecVarType, ghPropertyName string, syncName string) bool {
+	contenders := o[secVarType][ghPropertyName]
+	return len(contenders) == 0 || contenders[0] == syncName
+}
+
+// Describes every property defined by several syncs, empty if none is
+func (o ghPropertyOwners) conflicts() []string {
+	conflicts := []string{}
+	for _, secVarType := range AllGithubActionSecVarTypes {
+		for ghPropertyName, contenders := range o[secVarType] {
+			if len(contenders) < 2 {
+				continue
+			}
+			conflicts = append(conflicts, fmt.Sprintf("%s '%s' defined by %s, '%s' wins",
+				secVarType.String(), ghPropertyName, strings.Join(contenders, ", "), contenders[0]))
+		}
+	}
+	slices.Sort(conflicts)
+	return conflicts
+}
+
+// Reports on the repository whether several syncs define a same property
+func defineConflictStatus(repoCRD *qalisav1alpha1.GithubSyncRepo, owners ghPropertyOwners) {
+	conflicts := owners.conflicts()
+	if len(conflicts) == 0 {
+		setStatusCondition(repoCRD, &repoCRD.Status.Conditions, "Conflict", "False", "No conflict")
+		return
+	}
+	setStatusCondition(repoCRD, &repoCRD.Status.Conditions, "Conflict", "True", strings.Join(conflicts, "; "))
+}
+
+// Orders the syncs defining each property of the buffer: highest priority first, then by name
+func resolveGHPropertyOwners(secVarsToSync SecVarsBySync, appliedSyncs []qalisav1alpha1.GithubActionSecretsSync) ghPropertyOwners {
+	priorities := map[string]int32{}
+	for _, sync := range appliedSyncs {
+		priorities[sync.Name] = sync.Spec.Priority
+	}
+
+	//
+	owners := ghPropertyOwners{}
+	for secVarType, buckets := range secVarsToSync {
+		owners[secVarType] = map[string][]string{}
+		for syncNsName, bucket := range buckets {
+			for ghPropertyName := range bucket {
+				owners[secVarType][ghPropertyName] = append(owners[secVarType][ghPropertyName], syncNsName.Name)
+			}
+		}
+
+		//
+		for _, contenders := range owners[secVarType] {
+			slices.SortFunc(contenders, func(a, b string) int {
+				if priorities[a] != priorities[b] {
+					return cmp.Compare(priorities[b], priorities[a])
+				}
+				return strings.Compare(a, b)
+			})
+		}
+	}
+
+	return owners
+}
+
+// Copies the buffer, adding the desired state of applied syncs it misses, so that conflicts between them can be told.
+// Syncs whose desired state cannot be prepared are left out, their own reconciliation reporting why.
+func completeSyncBuffer(ctx context.Context, c client.Client, logger logr.Logger, secVarsToSync SecVarsBySync, appliedSyncs []qalisav1alpha1.GithubActionSecretsSync) SecVarsBySync {
+	completed := SecVarsBySync{}
+	for secVarType, buckets := range secVarsToSync {
+		completed[secVarType] = map[types.NamespacedName]map[string]SecVar{}
+		for syncNsName, bucket := range buckets {
+			completed[secVarType][syncNsName] = bucket
+		}
+	}
+
+	//
+	for _, sync := range appliedSyncs {
+		// suspended syncs are left out, so that nothing they define is pushed nor pruned
+		if sync.Spec.Suspend || secVarsToSync.HasSync(Variable, sync.Name) {
+			continue
+		}
+
+		// fill apart, a partially filled bucket would pass for the whole desired state
+		var syncSecVars SecVarsBySync
+		if err := FillSyncBuffer(ctx, c, &sync, &syncSecVars); err != nil {
+			logger.Info("Could not prepare secrets and variables of applied sync, ignoring it for conflicts",
+				"sync", sync.Name,
+				"error", err,
+			)
+			continue
+		}
+
+		//
+		for secVarType, buckets := range syncSecVars {
+			if completed[secVarType] == nil {
+				completed[secVarType] = map[types.NamespacedName]map[string]SecVar{}
+			}
+			for syncNsName, bucket := range buckets {
+				completed[secVarType][syncNsName] = bucket
+			}
+		}
+	}
+
+	return completed
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestResolveGHPropertyOwners(t *testing.T) {
+	applied := func(name string, priority int32) qalisav1alpha1.GithubActionSecretsSync {
+		return qalisav1alpha1.GithubActionSecretsSync{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       qalisav1alpha1.GithubActionSecretsSyncSpec{Priority: priority},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		definedBy    []string
+		appliedSyncs []qalisav1alpha1.GithubActionSecretsSync
+		want         []string
+	}{
+		{
+			name:         "single sync",
+			definedBy:    []string{"alpha"},
+			appliedSyncs: []qalisav1alpha1.GithubActionSecretsSync{applied("alpha", 0)},
+			want:         []string{"alpha"},
+		},
+		{
+			name:         "same priority, ordered by name",
+			definedBy:    []string{"charlie", "alpha", "bravo"},
+			appliedSyncs: []qalisav1alpha1.GithubActionSecretsSync{applied("alpha", 0), applied("bravo", 0), applied("charlie", 0)},
+			want:         []string{"alpha", "bravo", "charlie"},
+		},
+		{
+			name:         "highest priority first",
+			definedBy:    []string{"alpha", "bravo", "charlie"},
+			appliedSyncs: []qalisav1alpha1.GithubActionSecretsSync{applied("alpha", -1), applied("bravo", 10), applied("charlie", 0)},
+			want:         []string{"bravo", "charlie", "alpha"},
+		},
+		{
+			name:         "sync without known priority counts as zero",
+			definedBy:    []string{"alpha", "unknown"},
+			appliedSyncs: []qalisav1alpha1.GithubActionSecretsSync{applied("alpha", -1)},
+			want:         []string{"unknown", "alpha"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := SecVarsBySync{Secret: {}}
+			for _, syncName := range tt.definedBy {
+				buffer[Secret][types.NamespacedName{Name: syncName}] = map[string]SecVar{"TOKEN": {}}
+			}
+
+			owners := resolveGHPropertyOwners(buffer, tt.appliedSyncs)
+			if got := owners[Secret]["TOKEN"]; !slices.Equal(got, tt.want) {
+				t.Fatalf("resolveGHPropertyOwners() contenders = %v, want %v", got, tt.want)
+			}
+			for i, syncName := range tt.want {
+				if got := owners.isOwnedBy(Secret, "TOKEN", syncName); got != (i == 0) {
+					t.Errorf("isOwnedBy(%s) = %v, want %v", syncName, got, i == 0)
+				}
+			}
+			if got := len(owners.conflicts()) > 0; got != (len(tt.want) > 1) {
+				t.Errorf("conflicts() reported = %v, want %v", got, len(tt.want) > 1)
+			}
+		})
+	}
+}
+
+func TestGHPropertyOwnersIsOwnedByUndefined(t *testing.T) {
+	owners := resolveGHPropertyOwners(SecVarsBySync{}, nil)
+	if !owners.isOwnedBy(Variable, "UNDEFINED", "any") {
+		t.Errorf("isOwnedBy() = false for a property no sync defines, want true")
+	}
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
+)
+
+const (
+	// Label set on the GithubSyncRepo created by a GithubRepoDiscovery, holding its UID as names may exceed label values length.
+	// Its name is told by the owner reference.
+	DiscoveryLabel = "qalisa.github.io/discovery"
+	// Annotation set on the GithubSyncRepo created by a GithubRepoDiscovery, holding the discovered repository full name
+	DiscoveredRepositoryAnnotation = "qalisa.github.io/repository"
+)
+
+// Keeps the repositories matching every criteria of the filter
+func FilterDiscoveredRepositories(filter qalisav1alpha1.RepositoryFilter, repositories []github.Repository) ([]github.Repository, error) {
+	var nameRegex *regexp.Regexp
+	if filter.NameRegex != "" {
+		var err error
+		nameRegex, err = regexp.Compile(filter.NameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nameRegex '%s': %w", filter.NameRegex, err)
+		}
+	}
+
+	//
+	matching := []github.Repository{}
+	for _, repo := range repositories {
+		if repo.Archived && !filter.IncludeArchived {
+			continue
+		}
+		if nameRegex != nil && !nameRegex.MatchString(repo.FullName) {
+			continue
+		}
+		if len(filter.Visibilities) > 0 && !Contains(filter.Visibilities, repo.Visibility) {
+			continue
+		}
+		if !containsAll(repo.Topics, filter.Topics) {
+			continue
+		}
+		matching = append(matching, repo)
+	}
+
+	return matching, nil
+}
+
+func containsAll(arr []string, targets []string) bool {
+	for _, target := range targets {
+		if !Contains(arr, target) {
+			return false
+		}
+	}
+	return true
+}
+
+var invalidResourceNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Name of the GithubSyncRepo created by a GithubRepoDiscovery for a repository, as a valid resource name
+func DiscoveredRepoName(discoveryName string, repoFullName string) string {
+	name := invalidResourceNameChars.ReplaceAllString(strings.ToLower(discoveryName+"-"+repoFullName), "-")
+	name = strings.Trim(name, "-")
+
+	// keep within resource names length limit, while staying unique
+	const maxLength = 253
+	if len(name) > maxLength {
+		suffix := fmt.Sprintf("-%x", HashBytes([]byte(repoFullName)))
+		name = strings.Trim(name[:maxLength-len(suffix)], "-") + suffix
+	}
+
+	return name
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Gap tolerated between Github clock and ours, when comparing the update time of a secret with its last sync
+const driftClockSkewTolerance = 30 * time.Second
+
+// A property as it currently lives on Github
+type remoteGHProperty struct {
+	updatedAt time.Time
+	// only disclosed for variables
+	value *string
+}
+
+// Lists the properties of a type as they currently live on Github.
+// Returns nil without error for types and targets drift detection is not supported for.
+func fetchRemoteGHProperties(ctx context.Context, ghCli github.Client, syncType GithubActionSecVarType, repo GithubRepository) (map[string]remoteGHProperty, error) {
+	if repo.IsOrganizationLevel() {
+		return nil, nil
+	}
+
+	//
+	remoteProps := map[string]remoteGHProperty{}
+
+	switch syncType {
+	case Secret:
+		var secrets []github.RemoteSecret
+		var err error
+		if repo.IsEnvironmentLevel() {
+			secrets, err = ghCli.ListEnvSecrets(ctx, repo.Org, repo.Name, repo.Environment)
+		} else {
+			secrets, err = ghCli.ListSecrets(ctx, repo.Org, repo.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			remoteProps[secret.Name] = remoteGHProperty{updatedAt: secret.UpdatedAt}
+		}
+
+	case Variable:
+		var variables []github.RemoteVariable
+		var err error
+		if repo.IsEnvironmentLevel() {
+			variables, err = ghCli.ListEnvVariables(ctx, repo.Org, repo.Name, repo.Environment)
+		} else {
+			variables, err = ghCli.ListVariables(ctx, repo.Org, repo.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, variable := range variables {
+			value := variable.Value
+			remoteProps[variable.Name] = remoteGHProperty{updatedAt: variable.UpdatedAt, value: &value}
+		}
+
+	default:
+		return nil, nil
+	}
+
+	return remoteProps, nil
+}
+
+// Describes how a property considered synced departed from what lives on Github, empty if it did not
+func ghPropertyDrift(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string, remoteProps map[string]remoteGHProperty, secvar SecVar) string {
+	remote, found := remoteProps[githubPropertyName]
+	if !found {
+		return "deleted on Github"
+	}
+
+	// variables values can be compared directly
+	if remote.value != nil {
+		if *remote.value != string(secvar.Value) {
+			return "value changed on Github"
+		}
+		return ""
+	}
+
+	// secrets values are never disclosed, rely on them being written after we last pushed them
+	state := findGHPropertyState(states, githubPropertyName, environment)
+	if state == nil {
+		return ""
+	}
+	lastSyncedTime := state.LastSyncedTime
+	if lastSyncedTime == nil {
+		// states recorded before push times were, the Synced condition is the closest we have
+		condition := getSyncedStatusCondition(&state.Conditions)
+		if condition == nil {
+			return ""
+		}
+		lastSyncedTime = &condition.LastTransitionTime
+	}
+	if remote.updatedAt.After(lastSyncedTime.Add(driftClockSkewTolerance)) {
+		return fmt.Sprintf("updated on Github at %s, after last sync", remote.updatedAt.Format(time.RFC3339))
+	}
+
+	return ""
+}
+
+// Records on the property state the outcome of the drift check
+func defineGHPropertyDriftStatus(instance metav1.Object, states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string, drift string) {
+	conditions := findGHPropertyStateConditions(states, githubPropertyName, environment)
+	if conditions == nil {
+		return
+	}
+
+	if drift == "" {
+		setStatusCondition(instance, conditions, "Drifted", "False", "Matches Github")
+	} else {
+		setStatusCondition(instance, conditions, "Drifted", "True", drift)
+	}
+}
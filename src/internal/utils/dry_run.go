@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"slices"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+)
+
+// Whether the sync named syncName, among those applied to the repository, asks for a dry run
+func isSyncDryRun(repoSyncs []qalisav1alpha1.GithubActionSecretsSync, syncName string) bool {
+	for _, sync := range repoSyncs {
+		if sync.Name == syncName {
+			return sync.Spec.DryRun
+		}
+	}
+	return false
+}
+
+// Tells whether pushing the property would create it on Github, or update it
+func planGHPropertyPush(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string) qalisav1alpha1.PlannedActionType {
+	if state := findGHPropertyState(states, githubPropertyName, environment); state != nil && state.ObservedValueHash != "" {
+		return qalisav1alpha1.PlannedActionUpdate
+	}
+	return qalisav1alpha1.PlannedActionCreate
+}
+
+// Records a change a dry run would have made, replacing the one previously planned on the same property
+func setPlannedAction(repo *qalisav1alpha1.GithubSyncRepo, action qalisav1alpha1.PlannedAction) {
+	forgetPlannedAction(repo, action.Type, action.GithubPropertyName, action.Environment)
+	repo.Status.PlannedActions = append(repo.Status.PlannedActions, action)
+}
+
+// Forgets the change planned on a property, once made or not needed anymore
+func forgetPlannedAction(repo *qalisav1alpha1.GithubSyncRepo, secVarType string, githubPropertyName string, environment string) {
+	repo.Status.PlannedActions = slices.DeleteFunc(repo.Status.PlannedActions, func(action qalisav1alpha1.PlannedAction) bool {
+		return action.Type == secVarType && action.GithubPropertyName == githubPropertyName && action.Environment == environment
+	})
+}
+
+// Forgets the changes planned for the syncs of the buffer, about to be planned again
+func forgetPlannedActionsOf(repo *qalisav1alpha1.GithubSyncRepo, secVarsToSync SecVarsBySync) {
+	repo.Status.PlannedActions = slices.DeleteFunc(repo.Status.PlannedActions, func(action qalisav1alpha1.PlannedAction) bool {
+		return secVarsToSync.HasSync(Variable, action.SyncRef)
+	})
+}
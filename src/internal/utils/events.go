@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the Events emitted on the resources
+const (
+	EventReasonSynced          = "Synced"
+	EventReasonPushFailed      = "PushFailed"
+	EventReasonPruneFailed     = "PruneFailed"
+	EventReasonMissingSource   = "MissingSource"
+	EventReasonSyncNotFound    = "SyncNotFound"
+	EventReasonDryRun          = "DryRun"
+	EventReasonInvalidSelector = "InvalidSelector"
+)
+
+// Emits an Event on the resource, if a recorder is available
+func RecordEvent(recorder record.EventRecorder, object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	recorder.Eventf(object, eventType, reason, messageFmt, args...)
+}
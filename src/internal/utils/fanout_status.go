@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reports on the sync that its secrets and variables could not be prepared, nor synced anywhere
+func DefineSyncPreparationFailure(sync *qalisav1alpha1.GithubActionSecretsSync, err error) {
+	transitionStatusCondition(sync, &sync.Status.Conditions, "Synced", "False", err.Error())
+	transitionStatusCondition(sync, &sync.Status.Conditions, "Ready", "False", err.Error())
+	sync.Status.ErrorMessage = err.Error()
+}
+
+// Reports on the sync the outcome of the sync run on each repository it applies to, and whether all are synced.
+// Repositories the run did not reach, postponed by rate limit, are left pending with what was known of them.
+// Suspended repositories count neither as synced nor failed.
+// Times and conditions only move when outcomes change, so that a run changing nothing leaves the status as is.
+func DefineFanOutStatus(sync *qalisav1alpha1.GithubActionSecretsSync, toApplyTo []*qalisav1alpha1.GithubSyncRepo, reports []RepoSyncReport) {
+	now := metav1.Now()
+	transitionStatusCondition(sync, &sync.Status.Conditions, "Synced", "True", "Secrets and variables prepared")
+
+	//
+	repositories := []qalisav1alpha1.RepositorySyncStatus{}
+	failures := []string{}
+	pending := 0
+	for _, repoCRD := range toApplyTo {
+		entry := qalisav1alpha1.RepositorySyncStatus{Name: repoCRD.Name}
+		if previous := findRepositorySyncStatus(sync.Status.Repositories, repoCRD.Name); previous != nil {
+			entry = *previous.DeepCopy()
+		}
+
+		//
+		reportIndex := slices.IndexFunc(reports, func(report RepoSyncReport) bool { return report.RepoName == repoCRD.Name })
+		switch {
+		case reportIndex >= 0 && reports[reportIndex].Suspended:
+			entry.Outcome = qalisav1alpha1.RepositorySyncOutcomeSuspended
+			entry.Message = reports[reportIndex].Message
+
+		case reportIndex < 0:
+			entry.Outcome = qalisav1alpha1.RepositorySyncOutcomePending
+			entry.Message = "Not reached by the last sync run, postponed"
+			pending++
+
+		case reports[reportIndex].Synced:
+			if entry.Outcome != qalisav1alpha1.RepositorySyncOutcomeSynced || entry.LastSyncTime == nil {
+				entry.LastSyncTime = &now
+			}
+			entry.Outcome = qalisav1alpha1.RepositorySyncOutcomeSynced
+			entry.SyncedCount = int32(reports[reportIndex].SyncedCount)
+			entry.FailedCount = int32(reports[reportIndex].FailedCount)
+			entry.Message = reports[reportIndex].Message
+
+		default:
+			entry.Outcome = qalisav1alpha1.RepositorySyncOutcomeFailed
+			entry.SyncedCount = int32(reports[reportIndex].SyncedCount)
+			entry.FailedCount = int32(reports[reportIndex].FailedCount)
+			entry.Message = reports[reportIndex].Message
+			failures = append(failures, fmt.Sprintf("%s: %s", repoCRD.Name, entry.Message))
+		}
+
+		//
+		repositories = append(repositories, entry)
+	}
+	slices.SortFunc(repositories, func(a, b qalisav1alpha1.RepositorySyncStatus) int { return strings.Compare(a.Name, b.Name) })
+	sync.Status.Repositories = repositories
+
+	//
+	sync.Status.ErrorMessage = strings.Join(failures, "; ")
+	if len(failures) > 0 || pending > 0 {
+		transitionStatusCondition(sync, &sync.Status.Conditions, "Ready", "False",
+			fmt.Sprintf("%d failed and %d pending of %d repositories", len(failures), pending, len(toApplyTo)))
+		return
+	}
+
+	//
+	if !meta.IsStatusConditionTrue(sync.Status.Conditions, "Ready") || sync.Status.LastSyncTime == nil {
+		sync.Status.LastSyncTime = &now
+	}
+	transitionStatusCondition(sync, &sync.Status.Conditions, "Ready", "True", fmt.Sprintf("Synced to %d repositories", len(toApplyTo)))
+}
+
+func findRepositorySyncStatus(repositories []qalisav1alpha1.RepositorySyncStatus, name string) *qalisav1alpha1.RepositorySyncStatus {
+	for i := range repositories {
+		if repositories[i].Name == name {
+			return &repositories[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
+)
+
+// Finalizer held by GithubSyncRepo and GithubActionSecretsSync, until what they pushed to Github is cleaned up
+const GithubCleanupFinalizer = "qalisa.github.io/github-cleanup"
+
+// Whether the properties pushed to Github must be removed along with the resource
+func ShouldDeleteFromGithub(policy qalisav1alpha1.DeletionPolicy) bool {
+	return policy != qalisav1alpha1.DeletionPolicyOrphan
+}
+
+// Deletes from Github API the properties tracked by the repository status and sourced from syncRef (any if empty), then forgets about them.
+// With orphan, properties are only forgotten and left untouched on Github.
+func CleanupFromGithub(ctx context.Context, logger logr.Logger, ghCli github.Client, repoCRD *qalisav1alpha1.GithubSyncRepo, syncRef string, orphan bool) error {
+	var errs []error
+
+	//
+	repo, err := ParseRepository(*repoCRD)
+	if err != nil {
+		// nothing could have been pushed on an unparsable repository
+		return nil
+	}
+
+	//
+	for _, syncType := range AllGithubActionSecVarTypes {
+		states := syncType.AssociatedSyncState(repoCRD)
+
+		// iterate over a copy, since states are removed along the way
+		for _, state := range append([]qalisav1alpha1.GithubPropertySyncState{}, *states...) {
+			if syncRef != "" && state.SyncRef != syncRef {
+				continue
+			}
+
+			//
+			if !orphan {
+				if err := DeleteAgainstGithubApiAs(ctx, ghCli, syncType, repo.InEnvironment(state.Environment), state.GithubPropertyName); err != nil {
+					logger.Info("Failed to clean up against Github API",
+						"repo", repo,
+						syncType.String(), state.GithubPropertyName,
+						"environment", state.Environment,
+						"error", err,
+					)
+					SetSyncedStatusCondition(repoCRD, findGHPropertyStateConditions(states, state.GithubPropertyName, state.Environment), "False", err.Error())
+					errs = append(errs, fmt.Errorf("%s '%s': %w", syncType.String(), state.GithubPropertyName, err))
+					continue
+				}
+
+				logger.Info("Successful cleaned up against Github API",
+					"repo", repo,
+					syncType.String(), state.GithubPropertyName,
+					"environment", state.Environment,
+				)
+			}
+
+			//
+			removeGHPropertyState(states, state.GithubPropertyName, state.Environment)
+		}
+	}
+
+	//
+	return errors.Join(errs...)
+}
+
+// Whether the repository status tracks any property sourced from syncRef
+func HasGHPropertiesFrom(repoCRD *qalisav1alpha1.GithubSyncRepo, syncRef string) bool {
+	for _, syncType := range AllGithubActionSecVarTypes {
+		for _, state := range *syncType.AssociatedSyncState(repoCRD) {
+			if state.SyncRef == syncRef {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,17 +2,46 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"github.com/qalisa/github-actions-secrets-operator/internal/metrics"
 	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// TODO: handle timeouts, requeue with "return ctrl.Result{RequeueAfter: time.Minute}, nil" ?
-func SynchronizeToGithub(ctx context.Context, cli client.Client, logger logr.Logger, ghCli github.Client, toApplyTo []*qalisav1alpha1.GithubSyncRepo, secVarsToSync SecVarsBySync) (ctrl.Result, error) {
+// Tunes how a sync run behaves
+type SyncOptions struct {
+	// Compares properties considered synced with what lives on Github, pushing again those changed by hand
+	DetectDrift bool
+	// Serializes work on a repository with other reconciles targeting it
+	Locks *RepoLocks
+	// Emits Events on the repositories, telling about failures and successful syncs
+	Recorder record.EventRecorder
+	// Records in status the changes that would be made on Github instead of making them
+	DryRun bool
+}
+
+// What a sync run did on a repository
+type RepoSyncReport struct {
+	RepoName    string
+	Suspended   bool
+	Synced      bool
+	SyncedCount int
+	FailedCount int
+	Message     string
+}
+
+// Syncs the buffer to every repository, reporting on those reached; once rate limited, remaining ones are left for later
+func SynchronizeToGithub(ctx context.Context, cli client.Client, logger logr.Logger, ghCli github.Client, toApplyTo []*qalisav1alpha1.GithubSyncRepo, secVarsToSync SecVarsBySync, opts SyncOptions) (ctrl.Result, []RepoSyncReport, error) {
 
 	//
 	//
@@ -23,15 +52,20 @@ func SynchronizeToGithub(ctx context.Context, cli client.Client, logger logr.Log
 
 	} else {
 		logger.Info("No repositories to sync, nothing to do.")
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, nil, nil
 	}
 
 	//
 	//
 	//
 
-	secVarTypes := []GithubActionSecVarType{Variable, Secret}
+	secVarTypes := AllGithubActionSecVarTypes
 	var ghPropsSyncStateDict *[]qalisav1alpha1.GithubPropertySyncState
+	// once Github API refuses requests, remaining work is postponed
+	var rateLimitedErr error
+	// repositories whose status changed meanwhile are done again later, without holding the others back
+	var conflictErrs []error
+	reports := []RepoSyncReport{}
 
 	//
 	//
@@ -39,6 +73,38 @@ func SynchronizeToGithub(ctx context.Context, cli client.Client, logger logr.Log
 	for _, repoCRD := range toApplyTo {
 		//
 		var resultStatsStr string
+		var appliedSyncs []string
+		var pruneEnabled bool
+		var repoSyncs []qalisav1alpha1.GithubActionSecretsSync
+		var repoSecVars SecVarsBySync
+		var owners ghPropertyOwners
+		var isDryRun func(syncRef string) bool
+
+		// wait for other reconciles writing to the same repository
+		unlock := opts.Locks.Lock(repoCRD)
+
+		// they may have written its status while waiting, work from the latest
+		if err := cli.Get(ctx, client.ObjectKeyFromObject(repoCRD), repoCRD); err != nil {
+			unlock()
+			logger.Info("Could not refresh repository before syncing, skipping",
+				"repo", repoCRD.Name,
+				"error", err,
+			)
+			reports = append(reports, RepoSyncReport{RepoName: repoCRD.Name, Message: err.Error()})
+			continue
+		}
+
+		// left untouched while suspended, whichever resource triggered the run
+		if repoCRD.Spec.Suspend {
+			unlock()
+			logger.Info("Repository suspended, skipping", "repo", repoCRD.Name)
+			reports = append(reports, RepoSyncReport{RepoName: repoCRD.Name, Suspended: true, Message: "Reconciliation suspended"})
+			continue
+		}
+		DefineSuspendedStatus(repoCRD, &repoCRD.Status.Conditions, false)
+
+		// whole repository in dry run, or only some syncs applied to it
+		repoDryRun := opts.DryRun || repoCRD.Spec.DryRun
 
 		//
 		syncAttempts := SyncAttemptsByType{}
@@ -60,60 +126,211 @@ func SynchronizeToGithub(ctx context.Context, cli client.Client, logger logr.Log
 		logger.Info("Checking...", "repo", repo)
 
 		//
-		for syncTypeInt := range secVarTypes {
-			//
+		// Make sure targeted environment exists
+		//
+		if repo.IsEnvironmentLevel() && !repoDryRun {
+			if err := ghCli.EnsureEnvironment(ctx, repo.Org, repo.Name, repo.Environment); err != nil {
+				SetSyncedStatusCondition(repoCRD, &repoCRD.Status.Conditions, "False", err.Error())
+				logger.Info("Failed to ensure environment exists against Github API",
+					"repo", repo,
+					"error", err,
+				)
+				if isRateLimited(err) {
+					rateLimitedErr = err
+				}
+				goto doRegisterStatus
+			}
+		}
+
+		//
+		// Syncs applied to the repository may define the same properties, only one of them must win
+		//
+		repoSyncs, err = listAppliedSyncs(ctx, cli, repoCRD)
+		if err != nil {
+			SetSyncedStatusCondition(repoCRD, &repoCRD.Status.Conditions, "False", err.Error())
+			logger.Info("Could not resolve syncs applied to repository",
+				"repo", repo,
+				"error", err,
+			)
+			goto doRegisterStatus
+		}
+		repoSecVars = completeSyncBuffer(ctx, cli, logger, secVarsToSync, repoSyncs)
+		owners = resolveGHPropertyOwners(repoSecVars, repoSyncs)
+		defineConflictStatus(repoCRD, owners)
+
+		//
+		// Syncs in dry run plan their changes again on each run
+		//
+		isDryRun = func(syncRef string) bool {
+			return repoDryRun || isSyncDryRun(repoSyncs, syncRef)
+		}
+		forgetPlannedActionsOf(repoCRD, secVarsToSync)
+
+		//
+		// Syncs applied to the repository, to know which properties are orphaned
+		//
+		pruneEnabled = IsPruneEnabled(repoCRD)
+		if pruneEnabled {
+			appliedSyncs, err = appliedSyncNames(ctx, cli, repoCRD)
+			if err != nil {
+				logger.Info("Could not resolve syncs applied to repository, skipping prune",
+					"repo", repo,
+					"error", err,
+				)
+				pruneEnabled = false
+			}
+		}
+
+		//
+		for _, syncType := range secVarTypes {
 			//
 			//
-
 			//
-			syncType := GithubActionSecVarType(syncTypeInt)
 
 			//
 			ghPropsSyncStateDict = syncType.AssociatedSyncState(repoCRD)
 
+			// properties not supporting environments land on the repository itself
+			typeRepo := repo
+			if !syncType.SupportsEnvironment() {
+				typeRepo = repo.InEnvironment("")
+			}
+
 			//
 			syncAttemptsOfType := syncAttempts[syncType]
 
+			// live state on Github, to catch changes made by hand
+			var remoteProps map[string]remoteGHProperty
+			if opts.DetectDrift {
+				remoteProps, err = fetchRemoteGHProperties(ctx, ghCli, syncType, typeRepo)
+				if isRateLimited(err) {
+					rateLimitedErr = err
+					SetSyncedStatusCondition(repoCRD, &repoCRD.Status.Conditions, "False", err.Error())
+					goto doRegisterStatus
+				}
+				if err != nil {
+					logger.Info("Failed to fetch live state against Github API, skipping drift detection",
+						"repo", typeRepo,
+						"type", syncType.String(),
+						"error", err,
+					)
+					remoteProps = nil
+				}
+			}
+
 			//
-			for _, propertiesBucket := range secVarsToSync[syncType] {
+			for syncNsName, propertiesBucket := range secVarsToSync[syncType] {
 				for propertytName, secVar := range propertiesBucket {
+					// another sync applied to the repository has precedence over this property
+					if !owners.isOwnedBy(syncType, propertytName, syncNsName.Name) {
+						logger.Info("Property defined by a sync of higher precedence, skipping.",
+							"repo", typeRepo,
+							syncType.String(), propertytName,
+							"sync", syncNsName.Name,
+						)
+						continue
+					}
+
 					//
 					syncAttemptsOfType.BumpTotal()
 
 					// try to find if already synced
-					if isGHPropertyAlreadySynced(ghPropsSyncStateDict, propertytName, secVar) {
-						syncAttemptsOfType.BumpNotNeeded()
-						logger.Info("Already synced against API with the same value, skipping.",
-							"repo", repo,
+					if isGHPropertyAlreadySynced(ghPropsSyncStateDict, propertytName, typeRepo.Environment, secVar) &&
+						(!typeRepo.IsOrganizationLevel() || isGHPropertySyncedAtGeneration(ghPropsSyncStateDict, propertytName, typeRepo.Environment, repoCRD.Generation)) {
+						tagGHPropertySource(ghPropsSyncStateDict, propertytName, typeRepo.Environment, syncNsName.Name, &secVar.Source)
+
+						//
+						drift := ""
+						if remoteProps != nil {
+							drift = ghPropertyDrift(ghPropsSyncStateDict, propertytName, typeRepo.Environment, remoteProps, secVar)
+							defineGHPropertyDriftStatus(repoCRD, ghPropsSyncStateDict, propertytName, typeRepo.Environment, drift)
+						}
+
+						if drift == "" {
+							syncAttemptsOfType.BumpNotNeeded()
+							logger.Info("Already synced against API with the same value, skipping.",
+								"repo", typeRepo,
+								syncType.String(), propertytName,
+							)
+							continue
+						}
+
+						//
+						syncAttemptsOfType.BumpDrifted()
+						logger.Info("Drift detected against Github API, restoring...",
+							"repo", typeRepo,
+							syncType.String(), propertytName,
+							"drift", drift,
+						)
+					}
+
+					// only tell what would be done
+					if isDryRun(syncNsName.Name) {
+						action := planGHPropertyPush(ghPropsSyncStateDict, propertytName, typeRepo.Environment)
+						setPlannedAction(repoCRD, qalisav1alpha1.PlannedAction{
+							Action:             action,
+							Type:               syncType.String(),
+							GithubPropertyName: propertytName,
+							Environment:        typeRepo.Environment,
+							SyncRef:            syncNsName.Name,
+						})
+						syncAttemptsOfType.BumpPlanned()
+						logger.Info("Dry run, planned sync against Github API",
+							"repo", typeRepo,
 							syncType.String(), propertytName,
+							"action", action,
 						)
+						RecordEvent(opts.Recorder, repoCRD, corev1.EventTypeNormal, EventReasonDryRun,
+							"Dry run: would %s %s '%s'", strings.ToLower(string(action)), syncType.String(), propertytName)
 						continue
 					}
 
 					//
 					logger.Info("Attempting sync...",
-						"repo", repo,
+						"repo", typeRepo,
 						syncType.String(), propertytName,
 					)
 
 					// if not, try to update w/ Github API
-					err := secVar.UpdateAgainstGithubApiAs(ctx, ghCli, syncType, repo, propertytName)
+					err := secVar.UpdateAgainstGithubApiAs(ctx, ghCli, syncType, typeRepo, propertytName)
 
 					if err != nil {
 						logger.Info("Failed to sync against Github API",
-							"repo", repo,
+							"repo", typeRepo,
 							syncType.String(), propertytName,
 							"error", err,
 						)
+						RecordEvent(opts.Recorder, repoCRD, corev1.EventTypeWarning, EventReasonPushFailed,
+							"Failed to push %s '%s': %s", syncType.String(), propertytName, err)
 					} else {
 						logger.Info("Successful synced against Github API",
-							"repo", repo,
+							"repo", typeRepo,
 							syncType.String(), propertytName,
 						)
+						forgetPlannedAction(repoCRD, syncType.String(), propertytName, typeRepo.Environment)
 					}
 
 					// whatever the result, define sync state
-					defineGHPropertySyncStatus(repoCRD, ghPropsSyncStateDict, propertytName, secVar, err, syncAttemptsOfType)
+					defineGHPropertySyncStatus(repoCRD, ghPropsSyncStateDict, propertytName, typeRepo.Environment, syncNsName.Name, secVar, err, syncAttemptsOfType)
+
+					//
+					if isRateLimited(err) {
+						rateLimitedErr = err
+						SetSyncedStatusCondition(repoCRD, &repoCRD.Status.Conditions, "False", err.Error())
+						goto doRegisterStatus
+					}
+				}
+			}
+
+			//
+			// remove from Github what is not wanted anymore
+			//
+
+			if pruneEnabled {
+				if err := pruneGHProperties(ctx, logger, ghCli, opts.Recorder, repoCRD, typeRepo, syncType, ghPropsSyncStateDict, repoSecVars, appliedSyncs, syncAttemptsOfType, isDryRun); err != nil {
+					rateLimitedErr = err
+					SetSyncedStatusCondition(repoCRD, &repoCRD.Status.Conditions, "False", err.Error())
+					goto doRegisterStatus
 				}
 			}
 		}
@@ -132,8 +349,14 @@ func SynchronizeToGithub(ctx context.Context, cli client.Client, logger logr.Log
 		//
 		if SyncAttempts_AnyHasFailed(syncAttempts) {
 			SetSyncedStatusCondition(repoCRD, &repoCRD.Status.Conditions, "False", fmt.Sprintf("Some synchronizations failed %s", resultStatsStr))
+		} else if SyncAttempts_AnyPlanned(syncAttempts) {
+			SetSyncedStatusCondition(repoCRD, &repoCRD.Status.Conditions, "False", fmt.Sprintf("Dry run, changes planned but not made %s", resultStatsStr))
 		} else {
 			SetSyncedStatusCondition(repoCRD, &repoCRD.Status.Conditions, "True", fmt.Sprintf("All properties synced %s", resultStatsStr))
+			RecordEvent(opts.Recorder, repoCRD, corev1.EventTypeNormal, EventReasonSynced, "All properties synced %s", resultStatsStr)
+			now := metav1.Now()
+			repoCRD.Status.LastSyncTime = &now
+			metrics.LastSuccessfulSync.WithLabelValues(repoCRD.Name).SetToCurrentTime()
 		}
 
 		//
@@ -141,15 +364,119 @@ func SynchronizeToGithub(ctx context.Context, cli client.Client, logger logr.Log
 		//
 
 	doRegisterStatus:
+		// whatever happened, account for what was attempted
+		SyncAttempts_Record(repoCRD.Name, syncAttempts)
+		defineRepoSyncCounts(repoCRD)
+		reports = append(reports, newRepoSyncReport(repoCRD, syncAttempts))
+
 		// now, try to update status
-		if err := cli.Status().Update(ctx, repoCRD); err != nil {
+		err = cli.Status().Update(ctx, repoCRD)
+		unlock()
+		if apierrors.IsConflict(err) {
+			logger.Info("GithubSyncRepo changed while syncing, will sync it again", "repo", repoCRD.Name)
+			reports[len(reports)-1].Synced = false
+			reports[len(reports)-1].Message = fmt.Sprintf("Could not save status: %s", err)
+			conflictErrs = append(conflictErrs, fmt.Errorf("GithubSyncRepo '%s': %w", repoCRD.Name, err))
+		} else if err != nil {
 			logger.Error(err, "Unexpected fatal error while saving status for current GithubSyncRepo; rescheduling reconciliation.")
-			return ctrl.Result{}, err
+			return ctrl.Result{}, reports, err
+		}
+
+		// no need to hit Github API again with remaining repositories, come back once it accepts requests
+		if result, limited := RequeueWhenRateLimited(rateLimitedErr); limited {
+			logger.Info("Rate limited by Github API, postponing sync run",
+				"repo", repo,
+				"requeueAfter", result.RequeueAfter,
+			)
+			return result, reports, nil
 		}
 	}
 
 	logger.Info("Sync run ended")
 
 	//
-	return ctrl.Result{}, nil
+	return ctrl.Result{}, reports, errors.Join(conflictErrs...)
+}
+
+// Sums up what the sync run did on the repository
+func newRepoSyncReport(repoCRD *qalisav1alpha1.GithubSyncRepo, syncAttempts SyncAttemptsByType) RepoSyncReport {
+	report := RepoSyncReport{RepoName: repoCRD.Name}
+	for _, attempts := range syncAttempts {
+		report.SyncedCount += attempts.SuccessfulWithSkipped()
+		report.FailedCount += attempts.failed
+	}
+
+	//
+	if condition := getSyncedStatusCondition(&repoCRD.Status.Conditions); condition != nil {
+		report.Synced = condition.Status == metav1.ConditionTrue
+		report.Message = condition.Message
+	}
+	return report
+}
+
+// Deletes from Github API the properties of a type the repository does not want anymore, and forgets about them once done.
+// Stops and returns the error once rate limited, other failures being recorded in status.
+func pruneGHProperties(ctx context.Context, logger logr.Logger, ghCli github.Client, recorder record.EventRecorder, repoCRD *qalisav1alpha1.GithubSyncRepo, repo GithubRepository, syncType GithubActionSecVarType, states *[]qalisav1alpha1.GithubPropertySyncState, secVarsToSync SecVarsBySync, appliedSyncs []string, syncAttempts *SyncAttempts, isDryRun func(syncRef string) bool) error {
+	// iterate over a copy, since states are removed along the way
+	for _, state := range append([]qalisav1alpha1.GithubPropertySyncState{}, *states...) {
+		if !isGHPropertyPrunable(appliedSyncs, repo, state, syncType, secVarsToSync) {
+			continue
+		}
+
+		// only tell what would be done
+		if isDryRun(state.SyncRef) {
+			setPlannedAction(repoCRD, qalisav1alpha1.PlannedAction{
+				Action:             qalisav1alpha1.PlannedActionDelete,
+				Type:               syncType.String(),
+				GithubPropertyName: state.GithubPropertyName,
+				Environment:        state.Environment,
+				SyncRef:            state.SyncRef,
+			})
+			syncAttempts.BumpPlanned()
+			logger.Info("Dry run, planned prune against Github API",
+				"repo", repo,
+				syncType.String(), state.GithubPropertyName,
+				"environment", state.Environment,
+			)
+			RecordEvent(recorder, repoCRD, corev1.EventTypeNormal, EventReasonDryRun,
+				"Dry run: would delete %s '%s'", syncType.String(), state.GithubPropertyName)
+			continue
+		}
+
+		//
+		logger.Info("Attempting prune...",
+			"repo", repo,
+			syncType.String(), state.GithubPropertyName,
+			"environment", state.Environment,
+			"syncRef", state.SyncRef,
+		)
+
+		//
+		if err := DeleteAgainstGithubApiAs(ctx, ghCli, syncType, repo.InEnvironment(state.Environment), state.GithubPropertyName); err != nil {
+			logger.Info("Failed to prune against Github API",
+				"repo", repo,
+				syncType.String(), state.GithubPropertyName,
+				"error", err,
+			)
+			SetSyncedStatusCondition(repoCRD, findGHPropertyStateConditions(states, state.GithubPropertyName, state.Environment), "False", err.Error())
+			RecordEvent(recorder, repoCRD, corev1.EventTypeWarning, EventReasonPruneFailed,
+				"Failed to prune %s '%s': %s", syncType.String(), state.GithubPropertyName, err)
+			syncAttempts.BumpFailed()
+			if isRateLimited(err) {
+				return err
+			}
+			continue
+		}
+
+		//
+		logger.Info("Successful pruned against Github API",
+			"repo", repo,
+			syncType.String(), state.GithubPropertyName,
+		)
+		removeGHPropertyState(states, state.GithubPropertyName, state.Environment)
+		forgetPlannedAction(repoCRD, syncType.String(), state.GithubPropertyName, state.Environment)
+		syncAttempts.BumpPruned()
+	}
+
+	return nil
 }
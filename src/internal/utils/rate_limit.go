@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Least delay before trying again once rate limited, in case clocks disagree on when the limit lifts
+const minRateLimitRequeue = time.Second
+
+// Whether err is due to Github API refusing requests for now
+func isRateLimited(err error) bool {
+	_, limited := github.RateLimitedUntil(err)
+	return limited
+}
+
+// Produces a result postponing the next reconcile until Github API accepts requests again, when err is due to rate limiting
+func RequeueWhenRateLimited(err error) (ctrl.Result, bool) {
+	until, limited := github.RateLimitedUntil(err)
+	if !limited {
+		return ctrl.Result{}, false
+	}
+
+	return ctrl.Result{RequeueAfter: max(time.Until(until), minRateLimitRequeue)}, true
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InvalidSelectorError tells the selector of an object cannot be parsed; it then matches nothing
+type InvalidSelectorError struct {
+	Object client.Object
+	// Selector field and kind of the object, e.g. "repoSelector of GithubActionSecretsSync"
+	Field string
+	Err   error
+}
+
+func (e *InvalidSelectorError) Error() string {
+	return fmt.Sprintf("invalid %s '%s': %s", e.Field, e.Object.GetName(), e.Err)
+}
+
+// Logs an invalid selector and emits a warning Event on the object holding it, so that its owner can fix it
+func ReportInvalidSelector(logger logr.Logger, recorder record.EventRecorder, err error) {
+	var invalidErr *InvalidSelectorError
+	if !errors.As(err, &invalidErr) {
+		return
+	}
+	logger.Info("Ignoring invalid selector", "object", invalidErr.Object.GetName(), "error", invalidErr.Error())
+	RecordEvent(recorder, invalidErr.Object, corev1.EventTypeWarning, EventReasonInvalidSelector, "%s", invalidErr.Error())
+}
+
+// Whether a label selector matches the labels of obj. A nil selector matches nothing.
+func selectorMatches(selector *metav1.LabelSelector, obj metav1.Object) (bool, error) {
+	if selector == nil {
+		return false, nil
+	}
+
+	//
+	parsed, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+
+	return parsed.Matches(labels.Set(obj.GetLabels())), nil
+}
+
+// Whether the sync is applied to the repository through selectors, either the repository syncSelector or the sync repoSelector.
+// An invalid selector matches nothing, the other one is still checked; each invalid one is told by an InvalidSelectorError.
+func isSyncSelectedFor(sync *qalisav1alpha1.GithubActionSecretsSync, repo *qalisav1alpha1.GithubSyncRepo) (bool, error) {
+	var errs []error
+	matches, err := selectorMatches(repo.Spec.SyncSelector, sync)
+	if err != nil {
+		errs = append(errs, &InvalidSelectorError{Object: repo, Field: "syncSelector of GithubSyncRepo", Err: err})
+	}
+	if matches {
+		return true, nil
+	}
+
+	//
+	matches, err = selectorMatches(sync.Spec.RepoSelector, repo)
+	if err != nil {
+		errs = append(errs, &InvalidSelectorError{Object: sync, Field: "repoSelector of GithubActionSecretsSync", Err: err})
+	}
+	return matches, errors.Join(errs...)
+}
+
+// Whether the sync is applied to the repository, either referenced by name or matched through selectors
+func IsSyncAppliedTo(sync *qalisav1alpha1.GithubActionSecretsSync, repo *qalisav1alpha1.GithubSyncRepo) (bool, error) {
+	if Contains(repo.Spec.SecretsSyncRefs, sync.Name) {
+		return true, nil
+	}
+	return isSyncSelectedFor(sync, repo)
+}
+
+// Lists the GithubActionSecretsSync applied to the repository through selectors, and not already referenced by name.
+// Objects with an invalid selector are reported and skipped, without holding the others back.
+func ListSyncsSelectedFor(ctx context.Context, c client.Client, logger logr.Logger, recorder record.EventRecorder, repo *qalisav1alpha1.GithubSyncRepo) ([]qalisav1alpha1.GithubActionSecretsSync, error) {
+	var allSyncs qalisav1alpha1.GithubActionSecretsSyncList
+	if err := c.List(ctx, &allSyncs); err != nil {
+		return nil, err
+	}
+
+	// reported once, not for every sync
+	if _, err := selectorMatches(repo.Spec.SyncSelector, repo); err != nil {
+		ReportInvalidSelector(logger, recorder, &InvalidSelectorError{Object: repo, Field: "syncSelector of GithubSyncRepo", Err: err})
+		repo = repo.DeepCopy()
+		repo.Spec.SyncSelector = nil
+	}
+
+	//
+	selected := []qalisav1alpha1.GithubActionSecretsSync{}
+	for _, sync := range allSyncs.Items {
+		if Contains(repo.Spec.SecretsSyncRefs, sync.Name) {
+			continue
+		}
+
+		matches, err := isSyncSelectedFor(&sync, repo)
+		if err != nil {
+			ReportInvalidSelector(logger, recorder, err)
+		}
+		if matches {
+			selected = append(selected, sync)
+		}
+	}
+
+	return selected, nil
+}
+
+// Lists the GithubSyncRepo the sync is applied to.
+// Objects with an invalid selector are reported and skipped, without holding the others back.
+func ListReposAppliedWith(ctx context.Context, c client.Client, logger logr.Logger, recorder record.EventRecorder, sync *qalisav1alpha1.GithubActionSecretsSync) ([]qalisav1alpha1.GithubSyncRepo, error) {
+	var allRepos qalisav1alpha1.GithubSyncRepoList
+	if err := c.List(ctx, &allRepos); err != nil {
+		return nil, err
+	}
+
+	// reported once, not for every repository
+	if _, err := selectorMatches(sync.Spec.RepoSelector, sync); err != nil {
+		ReportInvalidSelector(logger, recorder, &InvalidSelectorError{Object: sync, Field: "repoSelector of GithubActionSecretsSync", Err: err})
+		sync = sync.DeepCopy()
+		sync.Spec.RepoSelector = nil
+	}
+
+	//
+	applied := []qalisav1alpha1.GithubSyncRepo{}
+	for _, repo := range allRepos.Items {
+		matches, err := IsSyncAppliedTo(sync, &repo)
+		if err != nil {
+			ReportInvalidSelector(logger, recorder, err)
+		}
+		if matches {
+			applied = append(applied, repo)
+		}
+	}
+
+	return applied, nil
+}
+
+// Names of every GithubActionSecretsSync applied to the repository, by name or through selectors
+func appliedSyncNames(ctx context.Context, c client.Client, repo *qalisav1alpha1.GithubSyncRepo) ([]string, error) {
+	// invalid selectors were already reported by the controller listing relations
+	selected, err := ListSyncsSelectedFor(ctx, c, logr.Discard(), nil, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	names := append([]string{}, repo.Spec.SecretsSyncRefs...)
+	for _, sync := range selected {
+		names = append(names, sync.Name)
+	}
+	return names, nil
+}
+
+// Lists the GithubActionSecretsSync applied to the repository, by name or through selectors.
+// Referenced syncs missing from the cluster, and those being deleted, are left out.
+func listAppliedSyncs(ctx context.Context, c client.Client, repo *qalisav1alpha1.GithubSyncRepo) ([]qalisav1alpha1.GithubActionSecretsSync, error) {
+	candidates := []qalisav1alpha1.GithubActionSecretsSync{}
+	for _, name := range repo.Spec.SecretsSyncRefs {
+		sync := qalisav1alpha1.GithubActionSecretsSync{}
+		if err := c.Get(ctx, types.NamespacedName{Name: name}, &sync); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		candidates = append(candidates, sync)
+	}
+
+	//
+	// invalid selectors were already reported by the controller listing relations
+	selected, err := ListSyncsSelectedFor(ctx, c, logr.Discard(), nil, repo)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, selected...)
+
+	//
+	applied := []qalisav1alpha1.GithubActionSecretsSync{}
+	seen := map[string]bool{}
+	for _, sync := range candidates {
+		if seen[sync.Name] || !sync.DeletionTimestamp.IsZero() {
+			continue
+		}
+		seen[sync.Name] = true
+		applied = append(applied, sync)
+	}
+	return applied, nil
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsSyncSelectedFor(t *testing.T) {
+	matchingProd := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	invalid := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Unknown"}}}
+
+	tests := []struct {
+		name         string
+		syncSelector *metav1.LabelSelector
+		repoSelector *metav1.LabelSelector
+		want         bool
+		wantInvalid  string
+	}{
+		{name: "no selector", want: false},
+		{name: "selected by repository", syncSelector: matchingProd, want: true},
+		{name: "selected by sync", repoSelector: matchingProd, want: true},
+		{name: "invalid repository selector, selected by sync", syncSelector: invalid, repoSelector: matchingProd, want: true, wantInvalid: "syncSelector of GithubSyncRepo"},
+		{name: "invalid sync selector, selected by repository", syncSelector: matchingProd, repoSelector: invalid, want: true},
+		{name: "invalid sync selector only", repoSelector: invalid, want: false, wantInvalid: "repoSelector of GithubActionSecretsSync"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sync := &qalisav1alpha1.GithubActionSecretsSync{
+				ObjectMeta: metav1.ObjectMeta{Name: "sync", Labels: map[string]string{"env": "prod"}},
+				Spec:       qalisav1alpha1.GithubActionSecretsSyncSpec{RepoSelector: tt.repoSelector},
+			}
+			repo := &qalisav1alpha1.GithubSyncRepo{
+				ObjectMeta: metav1.ObjectMeta{Name: "repo", Labels: map[string]string{"env": "prod"}},
+				Spec:       qalisav1alpha1.GithubSyncRepoSpec{SyncSelector: tt.syncSelector},
+			}
+
+			got, err := isSyncSelectedFor(sync, repo)
+			if got != tt.want {
+				t.Errorf("isSyncSelectedFor() = %v, want %v", got, tt.want)
+			}
+
+			var invalidErr *InvalidSelectorError
+			if tt.wantInvalid == "" {
+				if err != nil {
+					t.Errorf("isSyncSelectedFor() error = %v, want none", err)
+				}
+			} else if !errors.As(err, &invalidErr) || invalidErr.Field != tt.wantInvalid {
+				t.Errorf("isSyncSelectedFor() error = %v, want invalid %s", err, tt.wantInvalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"sync"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+)
+
+// Serializes writes against the same Github repository, letting different repositories be worked on in parallel
+type RepoLocks struct {
+	mu    sync.Mutex
+	locks map[string]*repoLock
+}
+
+type repoLock struct {
+	sync.Mutex
+	// reconciles holding or waiting for the lock, to know when it can be forgotten
+	refs int
+}
+
+func NewRepoLocks() *RepoLocks {
+	return &RepoLocks{locks: map[string]*repoLock{}}
+}
+
+// Blocks until the Github repository targeted by the resource is free, returning the function releasing it.
+// A nil RepoLocks locks nothing.
+func (r *RepoLocks) Lock(repoCRD *qalisav1alpha1.GithubSyncRepo) (unlock func()) {
+	if r == nil {
+		return func() {}
+	}
+
+	//
+	key := repoLockKey(repoCRD)
+
+	r.mu.Lock()
+	lock, found := r.locks[key]
+	if !found {
+		lock = &repoLock{}
+		r.locks[key] = lock
+	}
+	lock.refs++
+	r.mu.Unlock()
+
+	//
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		r.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(r.locks, key)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Environments of a repository share its lock, as organization-level targets share the organization one
+func repoLockKey(repoCRD *qalisav1alpha1.GithubSyncRepo) string {
+	repo, err := ParseRepository(*repoCRD)
+	if err != nil {
+		// nothing will be written to Github anyway
+		return "crd/" + repoCRD.Name
+	}
+
+	if repo.IsOrganizationLevel() {
+		return repo.Org
+	}
+	return repo.Org + "/" + repo.Name
+}
@@ -1,10 +1,12 @@
 package utils
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -12,6 +14,24 @@ func SetSyncedStatusCondition(instance metav1.Object, conditions *[]metav1.Condi
 	setStatusCondition(instance, conditions, "Synced", status, message)
 }
 
+// Reports whether reconciliation of the resource is suspended
+func DefineSuspendedStatus(instance metav1.Object, conditions *[]metav1.Condition, suspended bool) {
+	if suspended {
+		transitionStatusCondition(instance, conditions, "Suspended", "True", "Reconciliation suspended")
+	} else {
+		transitionStatusCondition(instance, conditions, "Suspended", "False", "Reconciliation active")
+	}
+}
+
+// Reports which discovered repositories could not get their GithubSyncRepo created or updated
+func DefineAppliedStatus(instance metav1.Object, conditions *[]metav1.Condition, failed []string) {
+	if len(failed) == 0 {
+		transitionStatusCondition(instance, conditions, "Applied", "True", "GithubSyncRepo applied for every discovered repository")
+	} else {
+		transitionStatusCondition(instance, conditions, "Applied", "False", fmt.Sprintf("Could not apply GithubSyncRepo for: %s", strings.Join(failed, ", ")))
+	}
+}
+
 // Updates the status condition of the resource
 func setStatusCondition(instance metav1.Object, conditions *[]metav1.Condition, statusType, status, message string) {
 	condition := metav1.Condition{
@@ -33,6 +53,17 @@ func setStatusCondition(instance metav1.Object, conditions *[]metav1.Condition,
 	*conditions = append(*conditions, condition)
 }
 
+// Updates the status condition of the resource, moving its transition time only when its status flips
+func transitionStatusCondition(instance metav1.Object, conditions *[]metav1.Condition, statusType, status, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               statusType,
+		Status:             metav1.ConditionStatus(status),
+		ObservedGeneration: instance.GetGeneration(),
+		Reason:             strings.ReplaceAll(status, " ", ""),
+		Message:            message,
+	})
+}
+
 func getStatusCondition(conditions *[]metav1.Condition, statusType string) *metav1.Condition {
 	for i, existingCondition := range *conditions {
 		if existingCondition.Type == statusType {
@@ -47,48 +78,147 @@ func getSyncedStatusCondition(conditions *[]metav1.Condition) *metav1.Condition
 	return getStatusCondition(conditions, "Synced")
 }
 
+// Whether every repository had all its properties synced on its last run
+func AllReposSynced(repos []*qalisav1alpha1.GithubSyncRepo) bool {
+	for _, repo := range repos {
+		condition := getSyncedStatusCondition(&repo.Status.Conditions)
+		if condition == nil || condition.Status != metav1.ConditionTrue {
+			return false
+		}
+	}
+	return true
+}
+
 //
 //
 //
 
-func findGHPropertyStateConditions(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string) *[]metav1.Condition {
+// Properties are tracked by name and environment, the same name being allowed once per environment
+func findGHPropertyState(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string) *qalisav1alpha1.GithubPropertySyncState {
 	for i, state := range *states {
-		if state.GithubPropertyName == githubPropertyName {
-			return &(*states)[i].Conditions
+		if state.GithubPropertyName == githubPropertyName && state.Environment == environment {
+			return &(*states)[i]
 		}
 	}
 	return nil
 }
 
-func isGHPropertyAlreadySynced(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, secvar SecVar) bool {
-	conditions := findGHPropertyStateConditions(states, githubPropertyName)
+func findGHPropertyStateConditions(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string) *[]metav1.Condition {
+	state := findGHPropertyState(states, githubPropertyName, environment)
+	if state == nil {
+		return nil
+	}
+	return &state.Conditions
+}
 
-	// if no conditions, means nothing has ever synced
-	if conditions == nil {
+func removeGHPropertyState(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string) {
+	for i, state := range *states {
+		if state.GithubPropertyName == githubPropertyName && state.Environment == environment {
+			*states = append((*states)[:i], (*states)[i+1:]...)
+			return
+		}
+	}
+}
+
+// Records which GithubActionSecretsSync a property comes from, required to know when it can be pruned.
+// The value source, if given, is also recorded.
+func tagGHPropertySource(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string, syncRef string, sourceRef *qalisav1alpha1.PropertySourceRef) {
+	if state := findGHPropertyState(states, githubPropertyName, environment); state != nil {
+		state.SyncRef = syncRef
+		if sourceRef != nil {
+			state.SourceRef = sourceRef.DeepCopy()
+		}
+	}
+}
+
+func isGHPropertyAlreadySynced(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string, secvar SecVar) bool {
+	state := findGHPropertyState(states, githubPropertyName, environment)
+
+	// if no state, means nothing has ever synced
+	if state == nil {
 		return false
 	}
 
 	//
-	return secvar.isSyncedFrom(conditions)
+	secvar.migrateLegacyHashOf(state)
+	return secvar.isSyncedFrom(state)
+}
+
+// Whether the property was last synced against the current generation of the resource.
+// Matters on organization-level, where a change of visibility must be pushed even if values did not change.
+func isGHPropertySyncedAtGeneration(states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string, generation int64) bool {
+	conditions := findGHPropertyStateConditions(states, githubPropertyName, environment)
+	if conditions == nil {
+		return false
+	}
+
+	condition := getSyncedStatusCondition(conditions)
+	return condition != nil && condition.ObservedGeneration == generation
 }
 
 //
 //
 //
 
-func defineGHPropertySyncStatus(instance metav1.Object, states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, secvar SecVar, err error, syncAttempts *SyncAttempts) {
-	conditions := findGHPropertyStateConditions(states, githubPropertyName)
+func defineGHPropertySyncStatus(instance metav1.Object, states *[]qalisav1alpha1.GithubPropertySyncState, githubPropertyName string, environment string, syncRef string, secvar SecVar, err error, syncAttempts *SyncAttempts) {
+	state := findGHPropertyState(states, githubPropertyName, environment)
 
 	// means we need to create
-	if conditions == nil {
+	if state == nil {
 		*states = append(*states, qalisav1alpha1.GithubPropertySyncState{
 			GithubPropertyName: githubPropertyName,
+			Environment:        environment,
 			Conditions:         []metav1.Condition{},
 		})
 
 		//
-		conditions = findGHPropertyStateConditions(states, githubPropertyName)
+		state = findGHPropertyState(states, githubPropertyName, environment)
 	}
 
-	secvar.defineSyncStatusFrom(instance, conditions, err, syncAttempts)
+	tagGHPropertySource(states, githubPropertyName, environment, syncRef, nil)
+	secvar.defineSyncStatusFrom(instance, state, err, syncAttempts)
+}
+
+//
+//
+//
+
+// Whether the property, previously pushed by the operator, is not wanted anymore on the repository.
+// Only properties whose origin is known are considered: either their sync desired state is part of the buffer,
+// or their sync is not applied to the repository anymore. Properties left in another environment are never wanted.
+func isGHPropertyPrunable(appliedSyncs []string, repo GithubRepository, state qalisav1alpha1.GithubPropertySyncState, syncType GithubActionSecVarType, secVarsToSync SecVarsBySync) bool {
+	// origin unknown (status predating tracking), cannot tell if another sync still wants it
+	if state.SyncRef == "" {
+		return false
+	}
+
+	if state.Environment != repo.Environment {
+		return true
+	}
+
+	if secVarsToSync.IsDesired(syncType, state.GithubPropertyName) {
+		return false
+	}
+
+	return secVarsToSync.HasSync(syncType, state.SyncRef) || !Contains(appliedSyncs, state.SyncRef)
+}
+
+// Whether the repository allows deleting properties that are not wanted anymore
+func IsPruneEnabled(repo *qalisav1alpha1.GithubSyncRepo) bool {
+	return repo.Spec.Prune == nil || *repo.Spec.Prune
+}
+
+// Counts the properties tracked on the repository, and those whose last push succeeded
+func defineRepoSyncCounts(repo *qalisav1alpha1.GithubSyncRepo) {
+	var synced, total int32
+	for _, secVarType := range AllGithubActionSecVarTypes {
+		for _, state := range *secVarType.AssociatedSyncState(repo) {
+			total++
+			if condition := getSyncedStatusCondition(&state.Conditions); condition != nil && condition.Status == metav1.ConditionTrue {
+				synced++
+			}
+		}
+	}
+	repo.Status.SyncedCount = synced
+	repo.Status.TotalCount = total
 }
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"testing"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+)
+
+func TestIsGHPropertyPrunable(t *testing.T) {
+	repo := GithubRepository{Org: "org", Name: "repo"}
+	buffer := SecVarsBySync{
+		Secret: {
+			{Name: "owner"}: {"TOKEN": SecVar{}},
+			{Name: "other"}: {"SHARED": SecVar{}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		appliedSyncs []string
+		state        qalisav1alpha1.GithubPropertySyncState
+		want         bool
+	}{
+		{
+			name:         "origin unknown",
+			appliedSyncs: []string{"owner"},
+			state:        qalisav1alpha1.GithubPropertySyncState{GithubPropertyName: "LEGACY"},
+			want:         false,
+		},
+		{
+			name:         "sync removed from repository",
+			appliedSyncs: []string{"other"},
+			state:        qalisav1alpha1.GithubPropertySyncState{GithubPropertyName: "GONE", SyncRef: "removed"},
+			want:         true,
+		},
+		{
+			name:         "sync still present, not defining it anymore",
+			appliedSyncs: []string{"owner", "other"},
+			state:        qalisav1alpha1.GithubPropertySyncState{GithubPropertyName: "GONE", SyncRef: "owner"},
+			want:         true,
+		},
+		{
+			name:         "sync still present, still defining it",
+			appliedSyncs: []string{"owner", "other"},
+			state:        qalisav1alpha1.GithubPropertySyncState{GithubPropertyName: "TOKEN", SyncRef: "owner"},
+			want:         false,
+		},
+		{
+			name:         "sync still applied, but missing from buffer",
+			appliedSyncs: []string{"owner", "unprepared"},
+			state:        qalisav1alpha1.GithubPropertySyncState{GithubPropertyName: "KEPT", SyncRef: "unprepared"},
+			want:         false,
+		},
+		{
+			name:         "another sync owning the name",
+			appliedSyncs: []string{"other"},
+			state:        qalisav1alpha1.GithubPropertySyncState{GithubPropertyName: "SHARED", SyncRef: "removed"},
+			want:         false,
+		},
+		{
+			name:         "pushed to another environment",
+			appliedSyncs: []string{"owner"},
+			state:        qalisav1alpha1.GithubPropertySyncState{GithubPropertyName: "TOKEN", SyncRef: "owner", Environment: "staging"},
+			want:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGHPropertyPrunable(tt.appliedSyncs, repo, tt.state, Secret, buffer); got != tt.want {
+				t.Errorf("isGHPropertyPrunable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsPruneEnabled(t *testing.T) {
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name  string
+		prune *bool
+		want  bool
+	}{
+		{name: "defaults to enabled", prune: nil, want: true},
+		{name: "enabled", prune: &enabled, want: true},
+		{name: "disabled", prune: &disabled, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &qalisav1alpha1.GithubSyncRepo{Spec: qalisav1alpha1.GithubSyncRepoSpec{Prune: tt.prune}}
+			if got := IsPruneEnabled(repo); got != tt.want {
+				t.Errorf("IsPruneEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Spreads resyncs up to this fraction of the interval, so resources created together do not hit Github API at once
+const resyncJitterFactor = 0.1
+
+// Delay before the next periodic resync of a resource, 0 meaning none.
+// The interval of the resource wins over the operator-wide default.
+func ResyncAfter(interval *metav1.Duration, defaultInterval time.Duration) time.Duration {
+	resolved := defaultInterval
+	if interval != nil {
+		resolved = interval.Duration
+	}
+
+	if resolved <= 0 {
+		return 0
+	}
+
+	return wait.Jitter(resolved, resyncJitterFactor)
+}
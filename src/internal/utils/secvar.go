@@ -4,48 +4,152 @@ import (
 	"context"
 	"fmt"
 
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
 	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type SecVar struct {
 	Value       []byte
-	HashOfValue uint32
+	HashOfValue string
+	// where the value comes from, recorded in status once pushed
+	Source qalisav1alpha1.PropertySourceRef
 }
 
-func (r *SecVar) hashAsString() string {
-	return fmt.Sprint(r.HashOfValue)
-}
+// Message of the Synced condition of properties pushed, the hash of their value living apart
+const syncedMessage = "Value synced"
 
-func (r *SecVar) isSyncedFrom(conditions *[]metav1.Condition) bool {
-	condition := getSyncedStatusCondition(conditions)
+func (r *SecVar) isSyncedFrom(state *qalisav1alpha1.GithubPropertySyncState) bool {
+	condition := getSyncedStatusCondition(&state.Conditions)
 
-	// condition was never set, consider not synced
-	if condition == nil {
+	// condition was never set, or last push failed, consider not synced
+	if condition == nil || condition.Status != metav1.ConditionTrue {
 		return false
 	}
 
-	// message must contain property associated value's hash
-	return condition.Message == r.hashAsString()
+	// state must hold property associated value's hash
+	return state.ObservedValueHash == r.HashOfValue
+}
+
+// States predating observedValueHash hold a 32-bit FNV hash of the value as Synced condition message.
+// If it matches the value, the keyed hash takes over, without touching the condition transition time drift detection relies on.
+func (r *SecVar) migrateLegacyHashOf(state *qalisav1alpha1.GithubPropertySyncState) {
+	if state.ObservedValueHash != "" {
+		return
+	}
+
+	//
+	condition := getSyncedStatusCondition(&state.Conditions)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Message != fmt.Sprint(HashBytes(r.Value)) {
+		return
+	}
+
+	//
+	state.ObservedValueHash = r.HashOfValue
+	condition.Message = syncedMessage
 }
 
 // Will define the Synced status from conditions, depending on if an error is passed as argument
-func (r *SecVar) defineSyncStatusFrom(instance metav1.Object, conditions *[]metav1.Condition, err error, syncAttempts *SyncAttempts) {
+func (r *SecVar) defineSyncStatusFrom(instance metav1.Object, state *qalisav1alpha1.GithubPropertySyncState, err error, syncAttempts *SyncAttempts) {
+	now := metav1.Now()
+	state.LastAttemptTime = &now
+	state.ObservedGeneration = instance.GetGeneration()
+
+	//
 	if err == nil {
-		SetSyncedStatusCondition(instance, conditions, "True", r.hashAsString())
+		state.ObservedValueHash = r.HashOfValue
+		state.SourceRef = r.Source.DeepCopy()
+		state.LastSyncedTime = &now
+		state.FailureCount = 0
+		state.LastError = ""
+		SetSyncedStatusCondition(instance, &state.Conditions, "True", syncedMessage)
 		syncAttempts.BumpSuccessful()
 	} else {
-		SetSyncedStatusCondition(instance, conditions, "False", err.Error())
+		state.FailureCount++
+		state.LastError = err.Error()
+		SetSyncedStatusCondition(instance, &state.Conditions, "False", err.Error())
 		syncAttempts.BumpFailed()
 	}
 }
 
 func (r *SecVar) UpdateAgainstGithubApiAs(ctx context.Context, cli github.Client, asType GithubActionSecVarType, repo GithubRepository, ghPropName string) error {
+	if repo.IsOrganizationLevel() {
+		switch asType {
+		case Variable:
+			return cli.CreateOrUpdateOrgVariable(ctx, repo.Org, ghPropName, string(r.Value), repo.OrgAccess)
+		case Secret:
+			return cli.CreateOrUpdateOrgSecret(ctx, repo.Org, ghPropName, r.Value, repo.OrgAccess)
+		case DependabotSecret:
+			return cli.CreateOrUpdateOrgDependabotSecret(ctx, repo.Org, ghPropName, r.Value, repo.OrgAccess)
+		case CodespacesSecret:
+			return cli.CreateOrUpdateOrgCodespacesSecret(ctx, repo.Org, ghPropName, r.Value, repo.OrgAccess)
+		default:
+			return fmt.Errorf("undefined behavior with GithubActionSecVarType type '%d'", asType)
+		}
+	}
+
+	if repo.IsEnvironmentLevel() {
+		switch asType {
+		case Variable:
+			return cli.CreateOrUpdateEnvVariable(ctx, repo.Org, repo.Name, repo.Environment, ghPropName, string(r.Value))
+		case Secret:
+			return cli.CreateOrUpdateEnvSecret(ctx, repo.Org, repo.Name, repo.Environment, ghPropName, r.Value)
+		default:
+			return fmt.Errorf("undefined behavior with GithubActionSecVarType type '%d'", asType)
+		}
+	}
+
 	switch asType {
 	case Variable:
 		return cli.CreateOrUpdateVariable(ctx, repo.Org, repo.Name, ghPropName, string(r.Value))
 	case Secret:
 		return cli.CreateOrUpdateSecret(ctx, repo.Org, repo.Name, ghPropName, r.Value)
+	case DependabotSecret:
+		return cli.CreateOrUpdateDependabotSecret(ctx, repo.Org, repo.Name, ghPropName, r.Value)
+	case CodespacesSecret:
+		return cli.CreateOrUpdateCodespacesSecret(ctx, repo.Org, repo.Name, ghPropName, r.Value)
+	default:
+		return fmt.Errorf("undefined behavior with GithubActionSecVarType type '%d'", asType)
+	}
+}
+
+// Removes a property of the given type from Github API, a property already gone is not considered an error
+func DeleteAgainstGithubApiAs(ctx context.Context, cli github.Client, asType GithubActionSecVarType, repo GithubRepository, ghPropName string) error {
+	if repo.IsOrganizationLevel() {
+		switch asType {
+		case Variable:
+			return cli.DeleteOrgVariable(ctx, repo.Org, ghPropName)
+		case Secret:
+			return cli.DeleteOrgSecret(ctx, repo.Org, ghPropName)
+		case DependabotSecret:
+			return cli.DeleteOrgDependabotSecret(ctx, repo.Org, ghPropName)
+		case CodespacesSecret:
+			return cli.DeleteOrgCodespacesSecret(ctx, repo.Org, ghPropName)
+		default:
+			return fmt.Errorf("undefined behavior with GithubActionSecVarType type '%d'", asType)
+		}
+	}
+
+	if repo.IsEnvironmentLevel() {
+		switch asType {
+		case Variable:
+			return cli.DeleteEnvVariable(ctx, repo.Org, repo.Name, repo.Environment, ghPropName)
+		case Secret:
+			return cli.DeleteEnvSecret(ctx, repo.Org, repo.Name, repo.Environment, ghPropName)
+		default:
+			return fmt.Errorf("undefined behavior with GithubActionSecVarType type '%d'", asType)
+		}
+	}
+
+	switch asType {
+	case Variable:
+		return cli.DeleteVariable(ctx, repo.Org, repo.Name, ghPropName)
+	case Secret:
+		return cli.DeleteSecret(ctx, repo.Org, repo.Name, ghPropName)
+	case DependabotSecret:
+		return cli.DeleteDependabotSecret(ctx, repo.Org, repo.Name, ghPropName)
+	case CodespacesSecret:
+		return cli.DeleteCodespacesSecret(ctx, repo.Org, repo.Name, ghPropName)
 	default:
 		return fmt.Errorf("undefined behavior with GithubActionSecVarType type '%d'", asType)
 	}
@@ -3,6 +3,8 @@ package utils
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
 
 	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,20 +15,35 @@ import (
 type GithubActionSecVarType int
 
 const (
-	Variable GithubActionSecVarType = iota
-	Secret   GithubActionSecVarType = iota
+	Variable         GithubActionSecVarType = iota
+	Secret           GithubActionSecVarType = iota
+	DependabotSecret GithubActionSecVarType = iota
+	CodespacesSecret GithubActionSecVarType = iota
 )
 
+// Every type of property which can be synced, in order of processing
+var AllGithubActionSecVarTypes = []GithubActionSecVarType{Variable, Secret, DependabotSecret, CodespacesSecret}
+
 func (cs GithubActionSecVarType) String() string {
 	switch cs {
 	case Variable:
 		return "Variable"
 	case Secret:
 		return "Secret"
+	case DependabotSecret:
+		return "DependabotSecret"
+	case CodespacesSecret:
+		return "CodespacesSecret"
 	}
 	panic("Unexpected GithubActionSecVarType type")
 }
 
+// Whether Github allows scoping this type of property to a deployment environment.
+// Those which do not are synced at repository level, even if an environment is targeted.
+func (cs GithubActionSecVarType) SupportsEnvironment() bool {
+	return cs == Variable || cs == Secret
+}
+
 func (cs GithubActionSecVarType) StringMaybePlurals() string {
 	return cs.String() + "(s)"
 }
@@ -37,6 +54,10 @@ func (cs GithubActionSecVarType) AssociatedSyncState(repo *qalisav1alpha1.Github
 		return &repo.Status.VariablesSyncStates
 	case Secret:
 		return &repo.Status.SecretsSyncStates
+	case DependabotSecret:
+		return &repo.Status.DependabotSecretsSyncStates
+	case CodespacesSecret:
+		return &repo.Status.CodespacesSecretsSyncStates
 	}
 	panic("Unexpected GithubActionSecVarType type")
 }
@@ -50,6 +71,15 @@ type SecVarsBySync map[GithubActionSecVarType]map[types.NamespacedName]map[strin
 
 // SetSecVar safely initializes the nested maps and sets the SecVar value.
 func SafeSetSecVar(svs *SecVarsBySync, secVarType GithubActionSecVarType, source metav1.ObjectMeta, ghPropertyName string, secVar SecVar) {
+	bucket := safeEnsureSyncBucket(svs, secVarType, source)
+
+	// Assign the value
+	bucket[ghPropertyName] = secVar
+}
+
+// Safely initializes the nested maps up to the bucket of the source, then returns it.
+// An existing bucket, even empty, means the source desired state is known.
+func safeEnsureSyncBucket(svs *SecVarsBySync, secVarType GithubActionSecVarType, source metav1.ObjectMeta) map[string]SecVar {
 	if *svs == nil {
 		*svs = make(SecVarsBySync) // Ensure the outer map is initialized
 	}
@@ -65,8 +95,27 @@ func SafeSetSecVar(svs *SecVarsBySync, secVarType GithubActionSecVarType, source
 		(*svs)[secVarType][nsName] = make(map[string]SecVar)
 	}
 
-	// Assign the value
-	(*svs)[secVarType][nsName][ghPropertyName] = secVar
+	return (*svs)[secVarType][nsName]
+}
+
+// Whether the desired state of the sync named syncName is known by the buffer
+func (svs SecVarsBySync) HasSync(secVarType GithubActionSecVarType, syncName string) bool {
+	for nsName := range svs[secVarType] {
+		if nsName.Name == syncName {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether any sync of the buffer wants a property of this type and name
+func (svs SecVarsBySync) IsDesired(secVarType GithubActionSecVarType, ghPropertyName string) bool {
+	for _, bucket := range svs[secVarType] {
+		if _, exists := bucket[ghPropertyName]; exists {
+			return true
+		}
+	}
+	return false
 }
 
 //
@@ -74,62 +123,140 @@ func SafeSetSecVar(svs *SecVarsBySync, secVarType GithubActionSecVarType, source
 //
 
 func FillSyncBuffer(ctx context.Context, c client.Client, instance *qalisav1alpha1.GithubActionSecretsSync, dataBySync *SecVarsBySync) error {
+	// Register buckets upfront, so that an emptied sync is still known as such when pruning
+	for _, secVarType := range AllGithubActionSecVarTypes {
+		safeEnsureSyncBucket(dataBySync, secVarType, instance.ObjectMeta)
+	}
+
 	// Process secrets
-	for _, secretRef := range instance.Spec.Secrets {
-		// Get Secret
-		secret, err := GetSecret(ctx, c, secretRef.SecretRef)
-		if err != nil {
-			return fmt.Errorf("failed to get secret '%s' in namespace '%s': %v", secretRef.SecretRef, instance.Namespace, err)
-		}
+	secretRefsByType := map[GithubActionSecVarType][]qalisav1alpha1.SecretRef{
+		Secret:           instance.Spec.Secrets,
+		DependabotSecret: instance.Spec.DependabotSecrets,
+		CodespacesSecret: instance.Spec.CodespacesSecrets,
+	}
+	for secVarType, secretRefs := range secretRefsByType {
+		for _, secretRef := range secretRefs {
+			// Compose from several keys
+			if secretRef.Template != nil {
+				renderedValue, err := renderValueTemplate(ctx, c, secretRef.Template)
+				if err != nil {
+					return fmt.Errorf("failed to render template of '%s': %v", secretRef.GithubName(), err)
+				}
 
-		// checks for key
-		secretValue, exists := secret.Data[secretRef.Key]
-		if !exists {
-			return fmt.Errorf("key %s not found in secret %s", secretRef.Key, secretRef.SecretRef)
-		}
+				//
+				SafeSetSecVar(dataBySync, secVarType, instance.ObjectMeta, secretRef.GithubName(), SecVar{
+					Value:       renderedValue,
+					HashOfValue: HashValue(renderedValue),
+					Source:      qalisav1alpha1.PropertySourceRef{Kind: qalisav1alpha1.PropertySourceTemplate},
+				})
+				continue
+			}
 
-		//
-		githubSecretName := secretRef.GithubSecretName
-		if githubSecretName == "" {
-			githubSecretName = secretRef.Key
-		}
+			// Get Secret
+			secret, err := GetSecret(ctx, c, *secretRef.SecretRef)
+			if err != nil {
+				return fmt.Errorf("failed to get secret '%s' in namespace '%s': %v", *secretRef.SecretRef, instance.Namespace, err)
+			}
 
-		//
-		SafeSetSecVar(dataBySync, Secret, instance.ObjectMeta, githubSecretName, SecVar{
-			Value:       secretValue,
-			HashOfValue: HashBytes(secretValue),
-		})
+			//
+			keys, err := selectKeys(secret.Data, secretRef.Key, secretRef.KeyRegex)
+			if err != nil {
+				return fmt.Errorf("failed to select keys of secret %s: %v", *secretRef.SecretRef, err)
+			}
+
+			for _, key := range keys {
+				// checks for key
+				secretValue, exists := secret.Data[key]
+				if !exists {
+					return fmt.Errorf("key %s not found in secret %s", key, *secretRef.SecretRef)
+				}
+
+				//
+				SafeSetSecVar(dataBySync, secVarType, instance.ObjectMeta, secretRef.GithubNameOf(key), SecVar{
+					Value:       secretValue,
+					HashOfValue: HashValue(secretValue),
+					Source: qalisav1alpha1.PropertySourceRef{
+						Kind:      qalisav1alpha1.PropertySourceSecret,
+						Name:      secretRef.SecretRef.Name,
+						Namespace: secretRef.SecretRef.Namespace,
+						Key:       key,
+					},
+				})
+			}
+		}
 	}
 
 	// Process variables
 	for _, configMapRef := range instance.Spec.Variables {
-		// Get Secret
-		configMap, err := GetConfigMap(ctx, c, configMapRef.ConfigMapRef)
-		if err != nil {
-			return fmt.Errorf("failed to get Config Map '%s' in namespace '%s': %v", configMapRef.ConfigMapRef, instance.Namespace, err)
+		// Literal value
+		if configMapRef.ConfigMapRef == nil {
+			literalValue := []byte(configMapRef.Value)
+			SafeSetSecVar(dataBySync, Variable, instance.ObjectMeta, configMapRef.GithubName(), SecVar{
+				Value:       literalValue,
+				HashOfValue: HashValue(literalValue),
+				Source:      qalisav1alpha1.PropertySourceRef{Kind: qalisav1alpha1.PropertySourceValue},
+			})
+			continue
 		}
 
-		// checks for key
-		configValue, exists := configMap.Data[configMapRef.Key]
-		if !exists {
-			return fmt.Errorf("key %s not found in config map %s", configMapRef.Key, configMapRef.ConfigMapRef)
-
+		// Get Secret
+		configMap, err := GetConfigMap(ctx, c, *configMapRef.ConfigMapRef)
+		if err != nil {
+			return fmt.Errorf("failed to get Config Map '%s' in namespace '%s': %v", *configMapRef.ConfigMapRef, instance.Namespace, err)
 		}
 
 		//
-		githubVariableName := configMapRef.GithubVariableName
-		if githubVariableName == "" {
-			githubVariableName = configMapRef.Key
+		keys, err := selectKeys(configMap.Data, configMapRef.Key, configMapRef.KeyRegex)
+		if err != nil {
+			return fmt.Errorf("failed to select keys of config map %s: %v", *configMapRef.ConfigMapRef, err)
 		}
 
-		//
-		configValueAsBytes := []byte(configValue)
-		SafeSetSecVar(dataBySync, Variable, instance.ObjectMeta, githubVariableName, SecVar{
-			Value:       configValueAsBytes,
-			HashOfValue: HashBytes(configValueAsBytes),
-		})
+		for _, key := range keys {
+			// checks for key
+			configValue, exists := configMap.Data[key]
+			if !exists {
+				return fmt.Errorf("key %s not found in config map %s", key, *configMapRef.ConfigMapRef)
+
+			}
+
+			//
+			configValueAsBytes := []byte(configValue)
+			SafeSetSecVar(dataBySync, Variable, instance.ObjectMeta, configMapRef.GithubNameOf(key), SecVar{
+				Value:       configValueAsBytes,
+				HashOfValue: HashValue(configValueAsBytes),
+				Source: qalisav1alpha1.PropertySourceRef{
+					Kind:      qalisav1alpha1.PropertySourceConfigMap,
+					Name:      configMapRef.ConfigMapRef.Name,
+					Namespace: configMapRef.ConfigMapRef.Namespace,
+					Key:       key,
+				},
+			})
+		}
 	}
 
 	//
 	return nil
 }
+
+// Keys of the object a reference selects: its single key, or every key matching its regex, in order
+func selectKeys[V any](data map[string]V, key string, keyRegex string) ([]string, error) {
+	if keyRegex == "" {
+		return []string{key}, nil
+	}
+
+	//
+	matcher, err := regexp.Compile(keyRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	keys := []string{}
+	for candidate := range data {
+		if matcher.MatchString(candidate) {
+			keys = append(keys, candidate)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSelectKeys(t *testing.T) {
+	data := map[string]string{
+		"db-password": "",
+		"db-user":     "",
+		"api_key":     "",
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		keyRegex string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "single key, even missing", key: "absent", want: []string{"absent"}},
+		{name: "every key, sorted", keyRegex: ".*", want: []string{"api_key", "db-password", "db-user"}},
+		{name: "matching keys only", keyRegex: "^db-", want: []string{"db-password", "db-user"}},
+		{name: "unanchored match", keyRegex: "key", want: []string{"api_key"}},
+		{name: "no key matching", keyRegex: "^none$", want: []string{}},
+		{name: "invalid regex", keyRegex: "(", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectKeys(data, tt.key, tt.keyRegex)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("selectKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"fmt"
 	"strings"
+
+	"github.com/qalisa/github-actions-secrets-operator/internal/metrics"
 )
 
 // Register all attempts of sync against Github API in a single sync run on a repo
@@ -10,6 +12,9 @@ type SyncAttempts struct {
 	notNeeded  int
 	successful int
 	failed     int
+	pruned     int
+	drifted    int
+	planned    int
 	total      int
 }
 
@@ -17,6 +22,9 @@ func (r *SyncAttempts) BumpTotal()      { r.total++ }
 func (r *SyncAttempts) BumpFailed()     { r.failed++ }
 func (r *SyncAttempts) BumpNotNeeded()  { r.notNeeded++ }
 func (r *SyncAttempts) BumpSuccessful() { r.successful++ }
+func (r *SyncAttempts) BumpPruned()     { r.pruned++ }
+func (r *SyncAttempts) BumpDrifted()    { r.drifted++ }
+func (r *SyncAttempts) BumpPlanned()    { r.planned++ }
 
 // if failed to sync a property, even once
 func (r *SyncAttempts) HasEverFailed() bool { return r.failed > 0 }
@@ -42,6 +50,16 @@ func SyncAttempts_AnyHasFailed(attempsByTypes SyncAttemptsByType) bool {
 	return false
 }
 
+// if a dry run left changes planned instead of making them
+func SyncAttempts_AnyPlanned(attempsByTypes SyncAttemptsByType) bool {
+	for _, attemps := range attempsByTypes {
+		if attemps.planned > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func SyncAttempts_ProduceStats(attempsByTypes SyncAttemptsByType) string {
 	//
 	statsByType := []string{}
@@ -53,9 +71,34 @@ func SyncAttempts_ProduceStats(attempsByTypes SyncAttemptsByType) string {
 			attemps.SuccessfulWithSkipped(), attemps.Total(),
 			strings.ToLower(attemptType.StringMaybePlurals()),
 		)
+		if attemps.pruned > 0 {
+			statStr += fmt.Sprintf(" (%d pruned)", attemps.pruned)
+		}
+		if attemps.drifted > 0 {
+			statStr += fmt.Sprintf(" (%d drifted)", attemps.drifted)
+		}
+		if attemps.planned > 0 {
+			statStr += fmt.Sprintf(" (%d planned)", attemps.planned)
+		}
 		statsByType = append(statsByType, statStr)
 	}
 
 	//
 	return fmt.Sprintf("(Synced: %s)", strings.Join(statsByType, " | "))
 }
+
+// Feeds the outcomes of a sync run on a repository to metrics
+func SyncAttempts_Record(repoName string, attempsByTypes SyncAttemptsByType) {
+	for attemptType, attemps := range attempsByTypes {
+		syncTypeStr := attemptType.String()
+		for outcome, count := range map[string]int{
+			"synced":  attemps.successful,
+			"skipped": attemps.notNeeded,
+			"failed":  attemps.failed,
+			"pruned":  attemps.pruned,
+			"drifted": attemps.drifted,
+		} {
+			metrics.PropertySyncs.WithLabelValues(repoName, syncTypeStr, outcome).Add(float64(count))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Functions available to templates, on top of text/template built-ins
+var valueTemplateFuncs = template.FuncMap{
+	"b64enc": func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	},
+	"toJson": func(value string) (string, error) {
+		quoted, err := json.Marshal(value)
+		return string(quoted), err
+	},
+}
+
+// Parses the text of the template, failing on values it does not know
+func ParseValueTemplate(text string) (*template.Template, error) {
+	return template.New("value").Option("missingkey=error").Funcs(valueTemplateFuncs).Parse(text)
+}
+
+// Renders the template with the values of the Secret and ConfigMap keys it names
+func renderValueTemplate(ctx context.Context, c client.Client, valueTemplate *qalisav1alpha1.ValueTemplate) ([]byte, error) {
+	values := map[string]string{}
+	for _, value := range valueTemplate.Values {
+		switch {
+		case value.SecretRef != nil:
+			secret, err := GetSecret(ctx, c, *value.SecretRef)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get secret '%s': %v", *value.SecretRef, err)
+			}
+			data, exists := secret.Data[value.Key]
+			if !exists {
+				return nil, fmt.Errorf("key %s not found in secret %s", value.Key, *value.SecretRef)
+			}
+			values[value.Name] = string(data)
+
+		case value.ConfigMapRef != nil:
+			configMap, err := GetConfigMap(ctx, c, *value.ConfigMapRef)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get Config Map '%s': %v", *value.ConfigMapRef, err)
+			}
+			data, exists := configMap.Data[value.Key]
+			if !exists {
+				return nil, fmt.Errorf("key %s not found in config map %s", value.Key, *value.ConfigMapRef)
+			}
+			values[value.Name] = data
+		}
+	}
+
+	//
+	parsed, err := ParseValueTemplate(valueTemplate.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	if err := parsed.Execute(&rendered, values); err != nil {
+		return nil, err
+	}
+	return rendered.Bytes(), nil
+}
@@ -2,11 +2,15 @@ package utils
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"hash/fnv"
 	"strings"
 
 	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"github.com/qalisa/github-actions-secrets-operator/pkg/github"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -23,6 +27,22 @@ func HashBytes(s []byte) uint32 {
 	return h.Sum32()
 }
 
+// Key of the HMAC hashing values recorded in status, so that they cannot be guessed from there
+var valueHashKey []byte
+
+// SetValueHashKey defines the key values are hashed with, to call once at startup.
+// Changing it makes every property considered changed, and pushed again.
+func SetValueHashKey(key []byte) {
+	valueHashKey = key
+}
+
+// Hashes a value to record in status, as hex encoded HMAC-SHA256
+func HashValue(value []byte) string {
+	mac := hmac.New(sha256.New, valueHashKey)
+	mac.Write(value)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // Helper function to check if a value exists in an array
 func Contains(arr []string, target string) bool {
 	for _, item := range arr {
@@ -40,10 +60,48 @@ func Contains(arr []string, target string) bool {
 type GithubRepository struct {
 	Org  string
 	Name string
+	// Deployment environment of the repository, if any
+	Environment string
+	// Only relevant on organization-level, which have no Name
+	OrgAccess github.OrgAccess
+}
+
+// Whether secrets and variables are to be synced against the organization itself rather than one of its repositories
+func (r GithubRepository) IsOrganizationLevel() bool {
+	return r.Name == ""
+}
+
+// Whether secrets and variables are to be synced against a deployment environment of the repository
+func (r GithubRepository) IsEnvironmentLevel() bool {
+	return !r.IsOrganizationLevel() && r.Environment != ""
+}
+
+// Same repository, targeting another environment (none if empty)
+func (r GithubRepository) InEnvironment(env string) GithubRepository {
+	r.Environment = env
+	return r
 }
 
-// ParseRepository splits a repository string in the format "owner/repo" into owner and repo parts
+// ParseRepository splits a repository string in the format "owner/repo" into owner and repo parts,
+// or describes the organization when targeting organization-level secrets and variables
 func ParseRepository(repository qalisav1alpha1.GithubSyncRepo) (GithubRepository, error) {
+	//
+	if org := repository.Spec.Organization; org != nil {
+		if repository.Spec.Repository != "" {
+			return GithubRepository{}, fmt.Errorf("both repository ('%s') and organization ('%s') are defined, expected only one", repository.Spec.Repository, org.Name)
+		}
+		if org.Name == "" {
+			return GithubRepository{}, fmt.Errorf("organization name must not be empty")
+		}
+		return GithubRepository{
+			Org: org.Name,
+			OrgAccess: github.OrgAccess{
+				Visibility:           org.Visibility,
+				SelectedRepositories: org.SelectedRepositories,
+			},
+		}, nil
+	}
+
 	//
 	toParse := repository.Spec.Repository
 	parts := strings.Split(toParse, "/")
@@ -54,7 +112,7 @@ func ParseRepository(repository qalisav1alpha1.GithubSyncRepo) (GithubRepository
 	}
 
 	//
-	return GithubRepository{parts[0], parts[1]}, nil
+	return GithubRepository{Org: parts[0], Name: parts[1], Environment: repository.Spec.Environment}, nil
 }
 
 //
@@ -0,0 +1,275 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+	"github.com/qalisa/github-actions-secrets-operator/internal/utils"
+)
+
+// log is for logging in this package.
+var githubactionsecretssynclog = logf.Log.WithName("githubactionsecretssync-resource")
+
+// SetupGithubActionSecretsSyncWebhookWithManager registers the webhook for GithubActionSecretsSync in the manager.
+func SetupGithubActionSecretsSyncWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&qalisav1alpha1.GithubActionSecretsSync{}).
+		WithValidator(&GithubActionSecretsSyncCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-qalisa-github-io-v1alpha1-githubactionsecretssync,mutating=false,failurePolicy=fail,sideEffects=None,groups=qalisa.github.io,resources=githubactionsecretssyncs,verbs=create;update,versions=v1alpha1,name=vgithubactionsecretssync-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GithubActionSecretsSyncCustomValidator rejects GithubActionSecretsSync whose GitHub names GitHub would refuse,
+// and warns about referenced Secrets and ConfigMaps not existing yet.
+type GithubActionSecretsSyncCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &GithubActionSecretsSyncCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type GithubActionSecretsSync.
+func (v *GithubActionSecretsSyncCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	sync, ok := obj.(*qalisav1alpha1.GithubActionSecretsSync)
+	if !ok {
+		return nil, fmt.Errorf("expected a GithubActionSecretsSync object but got %T", obj)
+	}
+	githubactionsecretssynclog.Info("Validation for GithubActionSecretsSync upon creation", "name", sync.GetName())
+
+	return v.validate(ctx, sync)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type GithubActionSecretsSync.
+func (v *GithubActionSecretsSyncCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	sync, ok := newObj.(*qalisav1alpha1.GithubActionSecretsSync)
+	if !ok {
+		return nil, fmt.Errorf("expected a GithubActionSecretsSync object for the newObj but got %T", newObj)
+	}
+	githubactionsecretssynclog.Info("Validation for GithubActionSecretsSync upon update", "name", sync.GetName())
+
+	// only spec changes are judged: finalizers must come and go even on resources predating the webhook
+	old, ok := oldObj.(*qalisav1alpha1.GithubActionSecretsSync)
+	if !ok {
+		return nil, fmt.Errorf("expected a GithubActionSecretsSync object for the oldObj but got %T", oldObj)
+	}
+	if !sync.DeletionTimestamp.IsZero() || equality.Semantic.DeepEqual(old.Spec, sync.Spec) {
+		return nil, nil
+	}
+
+	return v.validate(ctx, sync)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type GithubActionSecretsSync.
+func (v *GithubActionSecretsSyncCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *GithubActionSecretsSyncCustomValidator) validate(ctx context.Context, sync *qalisav1alpha1.GithubActionSecretsSync) (admission.Warnings, error) {
+	var allErrs field.ErrorList
+	var warnings admission.Warnings
+	specPath := field.NewPath("spec")
+
+	//
+	allErrs = append(allErrs, validateLabelSelector(specPath.Child("repoSelector"), sync.Spec.RepoSelector)...)
+
+	//
+	secretRefsByField := []struct {
+		name string
+		refs []qalisav1alpha1.SecretRef
+	}{
+		{"secrets", sync.Spec.Secrets},
+		{"dependabotSecrets", sync.Spec.DependabotSecrets},
+		{"codespacesSecrets", sync.Spec.CodespacesSecrets},
+	}
+	for _, byField := range secretRefsByField {
+		names := map[string]bool{}
+		for i, secretRef := range byField.refs {
+			refPath := specPath.Child(byField.name).Index(i)
+			if secretRef.KeyRegex != "" {
+				allErrs = append(allErrs, validateKeyRegex(refPath.Child("keyRegex"), secretRef.KeyRegex)...)
+			} else {
+				allErrs = append(allErrs, validateGithubName(refPath.Child("githubSecretName"), secretRef.GithubName(), names)...)
+			}
+			if secretRef.Template != nil {
+				allErrs = append(allErrs, validateTemplate(refPath.Child("template", "text"), secretRef.Template.Text)...)
+				warnings = append(warnings, v.warnMissingTemplateValues(ctx, refPath.Child("template", "values"), secretRef.Template)...)
+				continue
+			}
+			warnings = append(warnings, v.warnMissingSecret(ctx, refPath, secretRef)...)
+		}
+	}
+
+	//
+	names := map[string]bool{}
+	for i, configMapRef := range sync.Spec.Variables {
+		refPath := specPath.Child("variables").Index(i)
+		if configMapRef.KeyRegex != "" {
+			allErrs = append(allErrs, validateKeyRegex(refPath.Child("keyRegex"), configMapRef.KeyRegex)...)
+		} else {
+			allErrs = append(allErrs, validateGithubName(refPath.Child("githubVariableName"), configMapRef.GithubName(), names)...)
+		}
+		if configMapRef.ConfigMapRef != nil {
+			warnings = append(warnings, v.warnMissingConfigMap(ctx, refPath, configMapRef)...)
+		}
+	}
+
+	//
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+	return warnings, apierrors.NewInvalid(
+		qalisav1alpha1.GroupVersion.WithKind("GithubActionSecretsSync").GroupKind(),
+		sync.Name, allErrs)
+}
+
+//
+//
+//
+
+// GitHub only accepts alphanumeric characters and underscores, not starting with a number
+var githubNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Checks the name against GitHub naming rules, and that it was not seen yet; GitHub names being case insensitive
+func validateGithubName(path *field.Path, name string, seen map[string]bool) field.ErrorList {
+	var errs field.ErrorList
+	normalized := strings.ToUpper(name)
+
+	//
+	if !githubNameRegex.MatchString(name) {
+		errs = append(errs, field.Invalid(path, name, "must only contain alphanumeric characters or underscores, and not start with a number"))
+	}
+	if strings.HasPrefix(normalized, "GITHUB_") {
+		errs = append(errs, field.Invalid(path, name, "must not start with the GITHUB_ prefix, reserved by GitHub"))
+	}
+
+	//
+	if seen[normalized] {
+		errs = append(errs, field.Duplicate(path, name))
+	}
+	seen[normalized] = true
+
+	return errs
+}
+
+// Checks the regex selecting keys compiles
+func validateKeyRegex(path *field.Path, keyRegex string) field.ErrorList {
+	if _, err := regexp.Compile(keyRegex); err != nil {
+		return field.ErrorList{field.Invalid(path, keyRegex, err.Error())}
+	}
+	return nil
+}
+
+// Checks the label selector parses, as an invalid one would match nothing
+func validateLabelSelector(path *field.Path, selector *metav1.LabelSelector) field.ErrorList {
+	if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+		return field.ErrorList{field.Invalid(path, selector, err.Error())}
+	}
+	return nil
+}
+
+// Checks the template parses
+func validateTemplate(path *field.Path, text string) field.ErrorList {
+	if _, err := utils.ParseValueTemplate(text); err != nil {
+		return field.ErrorList{field.Invalid(path, text, err.Error())}
+	}
+	return nil
+}
+
+// Whether GitHub would accept the name
+func isValidGithubName(name string) bool {
+	return githubNameRegex.MatchString(name) && !strings.HasPrefix(strings.ToUpper(name), "GITHUB_")
+}
+
+// Warns when the single key does not exist yet in the object, or when no key matches the regex yet.
+// Keys matching the regex are also checked against GitHub naming rules, once expanded.
+func warnMissingKeys(path *field.Path, object string, keys []string, key string, keyRegex string, githubNameOf func(string) string) admission.Warnings {
+	if keyRegex == "" {
+		if !slices.Contains(keys, key) {
+			return admission.Warnings{fmt.Sprintf("%s: key '%s' does not exist yet in %s", path, key, object)}
+		}
+		return nil
+	}
+
+	// an invalid regex is already rejected
+	matcher, err := regexp.Compile(keyRegex)
+	if err != nil {
+		return nil
+	}
+
+	//
+	var warnings admission.Warnings
+	matched := false
+	for _, candidate := range keys {
+		if !matcher.MatchString(candidate) {
+			continue
+		}
+		matched = true
+		if name := githubNameOf(candidate); !isValidGithubName(name) {
+			warnings = append(warnings, fmt.Sprintf("%s: key '%s' of %s would be synced as '%s', which GitHub refuses", path, candidate, object, name))
+		}
+	}
+	if !matched {
+		warnings = append(warnings, fmt.Sprintf("%s: no key matches '%s' yet in %s", path, keyRegex, object))
+	}
+	return warnings
+}
+
+// Warns when the referenced Secret, or its keys, do not exist yet
+func (v *GithubActionSecretsSyncCustomValidator) warnMissingSecret(ctx context.Context, path *field.Path, secretRef qalisav1alpha1.SecretRef) admission.Warnings {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: secretRef.SecretRef.Namespace, Name: secretRef.SecretRef.Name}
+	if err := v.Client.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return admission.Warnings{fmt.Sprintf("%s: Secret '%s' does not exist yet", path, key)}
+		}
+		return nil
+	}
+
+	return warnMissingKeys(path, fmt.Sprintf("Secret '%s'", key), slices.Sorted(maps.Keys(secret.Data)), secretRef.Key, secretRef.KeyRegex, secretRef.GithubNameOf)
+}
+
+// Warns when the Secrets and ConfigMaps the template uses, or their keys, do not exist yet
+func (v *GithubActionSecretsSyncCustomValidator) warnMissingTemplateValues(ctx context.Context, path *field.Path, valueTemplate *qalisav1alpha1.ValueTemplate) admission.Warnings {
+	var warnings admission.Warnings
+	for i, value := range valueTemplate.Values {
+		valuePath := path.Index(i)
+		switch {
+		case value.SecretRef != nil:
+			warnings = append(warnings, v.warnMissingSecret(ctx, valuePath, qalisav1alpha1.SecretRef{SecretRef: value.SecretRef, Key: value.Key})...)
+		case value.ConfigMapRef != nil:
+			warnings = append(warnings, v.warnMissingConfigMap(ctx, valuePath, qalisav1alpha1.VariableRef{ConfigMapRef: value.ConfigMapRef, Key: value.Key})...)
+		}
+	}
+	return warnings
+}
+
+// Warns when the referenced ConfigMap, or its keys, do not exist yet
+func (v *GithubActionSecretsSyncCustomValidator) warnMissingConfigMap(ctx context.Context, path *field.Path, configMapRef qalisav1alpha1.VariableRef) admission.Warnings {
+	configMap := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: configMapRef.ConfigMapRef.Namespace, Name: configMapRef.ConfigMapRef.Name}
+	if err := v.Client.Get(ctx, key, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return admission.Warnings{fmt.Sprintf("%s: ConfigMap '%s' does not exist yet", path, key)}
+		}
+		return nil
+	}
+
+	return warnMissingKeys(path, fmt.Sprintf("ConfigMap '%s'", key), slices.Sorted(maps.Keys(configMap.Data)), configMapRef.Key, configMapRef.KeyRegex, configMapRef.GithubNameOf)
+}
@@ -0,0 +1,108 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	qalisav1alpha1 "github.com/qalisa/github-actions-secrets-operator/api/v1alpha1"
+)
+
+// log is for logging in this package.
+var githubsyncrepolog = logf.Log.WithName("githubsyncrepo-resource")
+
+// SetupGithubSyncRepoWebhookWithManager registers the webhook for GithubSyncRepo in the manager.
+func SetupGithubSyncRepoWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&qalisav1alpha1.GithubSyncRepo{}).
+		WithValidator(&GithubSyncRepoCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-qalisa-github-io-v1alpha1-githubsyncrepo,mutating=false,failurePolicy=fail,sideEffects=None,groups=qalisa.github.io,resources=githubsyncrepoes,verbs=create;update,versions=v1alpha1,name=vgithubsyncrepo-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// GithubSyncRepoCustomValidator rejects GithubSyncRepo referencing a same sync twice,
+// and warns about referenced GithubActionSecretsSync not existing yet.
+type GithubSyncRepoCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &GithubSyncRepoCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type GithubSyncRepo.
+func (v *GithubSyncRepoCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	repo, ok := obj.(*qalisav1alpha1.GithubSyncRepo)
+	if !ok {
+		return nil, fmt.Errorf("expected a GithubSyncRepo object but got %T", obj)
+	}
+	githubsyncrepolog.Info("Validation for GithubSyncRepo upon creation", "name", repo.GetName())
+
+	return v.validate(ctx, repo)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type GithubSyncRepo.
+func (v *GithubSyncRepoCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	repo, ok := newObj.(*qalisav1alpha1.GithubSyncRepo)
+	if !ok {
+		return nil, fmt.Errorf("expected a GithubSyncRepo object for the newObj but got %T", newObj)
+	}
+	githubsyncrepolog.Info("Validation for GithubSyncRepo upon update", "name", repo.GetName())
+
+	// only spec changes are judged: finalizers must come and go even on resources predating the webhook
+	old, ok := oldObj.(*qalisav1alpha1.GithubSyncRepo)
+	if !ok {
+		return nil, fmt.Errorf("expected a GithubSyncRepo object for the oldObj but got %T", oldObj)
+	}
+	if !repo.DeletionTimestamp.IsZero() || equality.Semantic.DeepEqual(old.Spec, repo.Spec) {
+		return nil, nil
+	}
+
+	return v.validate(ctx, repo)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type GithubSyncRepo.
+func (v *GithubSyncRepoCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *GithubSyncRepoCustomValidator) validate(ctx context.Context, repo *qalisav1alpha1.GithubSyncRepo) (admission.Warnings, error) {
+	var allErrs field.ErrorList
+	var warnings admission.Warnings
+	refsPath := field.NewPath("spec").Child("secretsSyncRefs")
+
+	//
+	allErrs = append(allErrs, validateLabelSelector(field.NewPath("spec").Child("syncSelector"), repo.Spec.SyncSelector)...)
+
+	//
+	seen := map[string]bool{}
+	for i, name := range repo.Spec.SecretsSyncRefs {
+		if seen[name] {
+			allErrs = append(allErrs, field.Duplicate(refsPath.Index(i), name))
+			continue
+		}
+		seen[name] = true
+
+		//
+		sync := &qalisav1alpha1.GithubActionSecretsSync{}
+		if err := v.Client.Get(ctx, types.NamespacedName{Name: name}, sync); apierrors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf("%s: GithubActionSecretsSync '%s' does not exist yet", refsPath.Index(i), name))
+		}
+	}
+
+	//
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+	return warnings, apierrors.NewInvalid(
+		qalisav1alpha1.GroupVersion.WithKind("GithubSyncRepo").GroupKind(),
+		repo.Name, allErrs)
+}
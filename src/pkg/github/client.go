@@ -4,8 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v60/github"
@@ -20,6 +18,64 @@ type Client interface {
 	// Variable operations
 	CreateOrUpdateVariable(ctx context.Context, owner, repo, name, value string) error
 	DeleteVariable(ctx context.Context, owner, repo, name string) error
+
+	// Environment secret operations
+	CreateOrUpdateEnvSecret(ctx context.Context, owner, repo, env, name string, value []byte) error
+	DeleteEnvSecret(ctx context.Context, owner, repo, env, name string) error
+
+	// Environment variable operations
+	CreateOrUpdateEnvVariable(ctx context.Context, owner, repo, env, name, value string) error
+	DeleteEnvVariable(ctx context.Context, owner, repo, env, name string) error
+
+	// Environment operations
+	EnsureEnvironment(ctx context.Context, owner, repo, env string) error
+
+	// Organization secret operations
+	CreateOrUpdateOrgSecret(ctx context.Context, org, name string, value []byte, access OrgAccess) error
+	DeleteOrgSecret(ctx context.Context, org, name string) error
+
+	// Organization variable operations
+	CreateOrUpdateOrgVariable(ctx context.Context, org, name, value string, access OrgAccess) error
+	DeleteOrgVariable(ctx context.Context, org, name string) error
+
+	// Dependabot secret operations
+	CreateOrUpdateDependabotSecret(ctx context.Context, owner, repo, name string, value []byte) error
+	DeleteDependabotSecret(ctx context.Context, owner, repo, name string) error
+	CreateOrUpdateOrgDependabotSecret(ctx context.Context, org, name string, value []byte, access OrgAccess) error
+	DeleteOrgDependabotSecret(ctx context.Context, org, name string) error
+
+	// Codespaces secret operations
+	CreateOrUpdateCodespacesSecret(ctx context.Context, owner, repo, name string, value []byte) error
+	DeleteCodespacesSecret(ctx context.Context, owner, repo, name string) error
+	CreateOrUpdateOrgCodespacesSecret(ctx context.Context, org, name string, value []byte, access OrgAccess) error
+	DeleteOrgCodespacesSecret(ctx context.Context, org, name string) error
+
+	// Installation operations
+	ListInstallationRepositories(ctx context.Context) ([]Repository, error)
+
+	// Live state operations, for drift detection
+	ListSecrets(ctx context.Context, owner, repo string) ([]RemoteSecret, error)
+	ListVariables(ctx context.Context, owner, repo string) ([]RemoteVariable, error)
+	ListEnvSecrets(ctx context.Context, owner, repo, env string) ([]RemoteSecret, error)
+	ListEnvVariables(ctx context.Context, owner, repo, env string) ([]RemoteVariable, error)
+}
+
+// Repository describes a repository accessible to the GitHub App installation
+type Repository struct {
+	Owner      string
+	Name       string
+	FullName   string
+	Topics     []string
+	Visibility string
+	Archived   bool
+}
+
+// OrgAccess defines which repositories of an organization can use an organization-level secret or variable
+type OrgAccess struct {
+	// Visibility is one of "all", "private" or "selected"
+	Visibility string
+	// SelectedRepositories are the names (without owner) of the repositories allowed, when Visibility is "selected"
+	SelectedRepositories []string
 }
 
 // Config holds the GitHub App configuration
@@ -27,11 +83,15 @@ type Config struct {
 	AppID          int64
 	InstallationID int64
 	PrivateKey     []byte
+	// Observer is told about every request made, if set
+	Observer Observer
 }
 
 type client struct {
-	ghClient *github.Client
-	config   Config
+	ghClient   *github.Client
+	config     Config
+	publicKeys *publicKeyCache
+	repoIDs    *repoIDCache
 }
 
 // NewClient creates a new GitHub client using GitHub App authentication
@@ -49,49 +109,59 @@ func NewClient(config Config) (Client, error) {
 
 	// Create GitHub client with retry and rate limit handling
 	httpClient := &http.Client{
-		Transport: &retryTransport{
-			base: itr,
+		Transport: &rateLimitTransport{
+			base:     itr,
+			observer: config.Observer,
 		},
 	}
 
 	return &client{
-		ghClient: github.NewClient(httpClient),
-		config:   config,
+		ghClient:   github.NewClient(httpClient),
+		config:     config,
+		publicKeys: newPublicKeyCache(publicKeyTTL),
+		repoIDs:    newRepoIDCache(repoIDTTL),
 	}, nil
 }
 
 // CreateOrUpdateSecret creates or updates a GitHub Actions secret
 func (c *client) CreateOrUpdateSecret(ctx context.Context, owner, repo, name string, value []byte) error {
-	// Get public key for secret encryption
-	key, _, err := c.ghClient.Actions.GetRepoPublicKey(ctx, owner, repo)
-	if err != nil {
-		return fmt.Errorf("failed to get repository public key: %w", err)
-	}
-
-	// Encrypt secret value using sodium library
-	encryptedBytes, err := encryptSecretWithPublicKey(value, key)
-	if err != nil {
-		return fmt.Errorf("failed to encrypt secret: %w", err)
-	}
-
-	// Create or update secret
-	secret := &github.EncryptedSecret{
-		Name:           name,
-		KeyID:          key.GetKeyID(),
-		EncryptedValue: encryptedBytes,
-	}
-	_, err = c.ghClient.Actions.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
-	if err != nil {
-		return fmt.Errorf("failed to create/update secret: %w", err)
-	}
+	return c.pushWithPublicKey("actions/"+owner+"/"+repo,
+		func() (*github.PublicKey, error) {
+			key, _, err := c.ghClient.Actions.GetRepoPublicKey(ctx, owner, repo)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get repository public key: %w", err)
+			}
+			return key, nil
+		},
+		func(key *github.PublicKey) (*github.Response, error) {
+			// Encrypt secret value using sodium library
+			encryptedBytes, err := encryptSecretWithPublicKey(value, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+			}
 
-	return nil
+			// Create or update secret
+			secret := &github.EncryptedSecret{
+				Name:           name,
+				KeyID:          key.GetKeyID(),
+				EncryptedValue: encryptedBytes,
+			}
+			resp, err := c.ghClient.Actions.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
+			if err != nil {
+				return resp, fmt.Errorf("failed to create/update secret: %w", err)
+			}
+			return resp, nil
+		},
+	)
 }
 
-// DeleteSecret deletes a GitHub Actions secret
+// DeleteSecret deletes a GitHub Actions secret, a secret already gone is not considered an error
 func (c *client) DeleteSecret(ctx context.Context, owner, repo, name string) error {
-	_, err := c.ghClient.Actions.DeleteRepoSecret(ctx, owner, repo, name)
+	resp, err := c.ghClient.Actions.DeleteRepoSecret(ctx, owner, repo, name)
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
 		return fmt.Errorf("failed to delete secret: %w", err)
 	}
 	return nil
@@ -134,56 +204,300 @@ func (c *client) CreateOrUpdateVariable(ctx context.Context, owner, repo, name,
 	return nil
 }
 
-// DeleteVariable deletes a GitHub Actions variable
+// DeleteVariable deletes a GitHub Actions variable, a variable already gone is not considered an error
 func (c *client) DeleteVariable(ctx context.Context, owner, repo, name string) error {
-	_, err := c.ghClient.Actions.DeleteRepoVariable(ctx, owner, repo, name)
+	resp, err := c.ghClient.Actions.DeleteRepoVariable(ctx, owner, repo, name)
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
 		return fmt.Errorf("failed to delete variable: %w", err)
 	}
 	return nil
 }
 
-// retryTransport implements a custom transport with retry logic and rate limit handling
-type retryTransport struct {
-	base http.RoundTripper
+// CreateOrUpdateEnvSecret creates or updates a GitHub Actions environment secret
+func (c *client) CreateOrUpdateEnvSecret(ctx context.Context, owner, repo, env, name string, value []byte) error {
+	repoID, err := c.repositoryID(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	//
+	return c.pushWithPublicKey("actions/"+owner+"/"+repo+"/environments/"+env,
+		func() (*github.PublicKey, error) {
+			key, _, err := c.ghClient.Actions.GetEnvPublicKey(ctx, repoID, env)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get environment public key: %w", err)
+			}
+			return key, nil
+		},
+		func(key *github.PublicKey) (*github.Response, error) {
+			// Encrypt secret value using sodium library
+			encryptedBytes, err := encryptSecretWithPublicKey(value, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+			}
+
+			// Create or update secret
+			secret := &github.EncryptedSecret{
+				Name:           name,
+				KeyID:          key.GetKeyID(),
+				EncryptedValue: encryptedBytes,
+			}
+			resp, err := c.ghClient.Actions.CreateOrUpdateEnvSecret(ctx, repoID, env, secret)
+			if err != nil {
+				return resp, fmt.Errorf("failed to create/update environment secret: %w", err)
+			}
+			return resp, nil
+		},
+	)
 }
 
-func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	var resp *http.Response
-	var err error
-	maxRetries := 3
-	backoff := time.Second
+// DeleteEnvSecret deletes a GitHub Actions environment secret, a secret already gone is not considered an error
+func (c *client) DeleteEnvSecret(ctx context.Context, owner, repo, env, name string) error {
+	repoID, err := c.repositoryID(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.ghClient.Actions.DeleteEnvSecret(ctx, repoID, env, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete environment secret: %w", err)
+	}
+	return nil
+}
+
+// CreateOrUpdateEnvVariable creates or updates a GitHub Actions environment variable
+func (c *client) CreateOrUpdateEnvVariable(ctx context.Context, owner, repo, env, name, value string) error {
+	repoID, err := c.repositoryID(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	//
+	variable := &github.ActionsVariable{
+		Name:  name,
+		Value: value,
+	}
+
+	//
+	resp, err := c.ghClient.Actions.UpdateEnvVariable(ctx, repoID, env, variable)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			// Variable doesn't exist, create it
+			_, err = c.ghClient.Actions.CreateEnvVariable(ctx, repoID, env, variable)
+			if err != nil {
+				return fmt.Errorf("failed to create environment variable: %w", err)
+			}
+		} else {
+			return fmt.Errorf("failed to update environment variable: %w", err)
+		}
+	}
+
+	return nil
+}
 
-	for i := 0; i < maxRetries; i++ {
-		resp, err = t.base.RoundTrip(req)
+// DeleteEnvVariable deletes a GitHub Actions environment variable, a variable already gone is not considered an error
+func (c *client) DeleteEnvVariable(ctx context.Context, owner, repo, env, name string) error {
+	repoID, err := c.repositoryID(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.ghClient.Actions.DeleteEnvVariable(ctx, repoID, env, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete environment variable: %w", err)
+	}
+	return nil
+}
+
+// EnsureEnvironment creates the deployment environment of the repository if it does not exist yet.
+// An existing environment is left untouched, keeping its protection rules.
+func (c *client) EnsureEnvironment(ctx context.Context, owner, repo, env string) error {
+	_, resp, err := c.ghClient.Repositories.GetEnvironment(ctx, owner, repo, env)
+	if err == nil {
+		return nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to get environment: %w", err)
+	}
+
+	// Environment doesn't exist, create it
+	_, _, err = c.ghClient.Repositories.CreateUpdateEnvironment(ctx, owner, repo, env, &github.CreateUpdateEnvironment{})
+	if err != nil {
+		return fmt.Errorf("failed to create environment: %w", err)
+	}
+
+	return nil
+}
+
+// ListInstallationRepositories lists every repository the GitHub App installation has access to
+func (c *client) ListInstallationRepositories(ctx context.Context) ([]Repository, error) {
+	repositories := []Repository{}
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		page, resp, err := c.ghClient.Apps.ListRepos(ctx, opts)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to list installation repositories: %w", err)
+		}
+
+		//
+		for _, repo := range page.Repositories {
+			repositories = append(repositories, Repository{
+				Owner:      repo.GetOwner().GetLogin(),
+				Name:       repo.GetName(),
+				FullName:   repo.GetFullName(),
+				Topics:     repo.Topics,
+				Visibility: repo.GetVisibility(),
+				Archived:   repo.GetArchived(),
+			})
+		}
+
+		//
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return repositories, nil
+}
+
+// repositoryID looks up the ID of a repository, which environment endpoints and organization access expect instead of its name
+func (c *client) repositoryID(ctx context.Context, owner, repo string) (int, error) {
+	return c.repoIDs.get(owner+"/"+repo, func() (int, error) {
+		repository, _, err := c.ghClient.Repositories.Get(ctx, owner, repo)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get repository: %w", err)
+		}
+		return int(repository.GetID()), nil
+	})
+}
+
+// CreateOrUpdateOrgSecret creates or updates a GitHub Actions organization secret
+func (c *client) CreateOrUpdateOrgSecret(ctx context.Context, org, name string, value []byte, access OrgAccess) error {
+	// Resolve repositories allowed to access the secret
+	selectedRepoIDs, err := c.resolveOrgAccess(ctx, org, access)
+	if err != nil {
+		return err
+	}
+
+	//
+	return c.pushWithPublicKey("actions/"+org,
+		func() (*github.PublicKey, error) {
+			key, _, err := c.ghClient.Actions.GetOrgPublicKey(ctx, org)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get organization public key: %w", err)
+			}
+			return key, nil
+		},
+		func(key *github.PublicKey) (*github.Response, error) {
+			// Encrypt secret value using sodium library
+			encryptedBytes, err := encryptSecretWithPublicKey(value, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+			}
+
+			// Create or update secret
+			secret := &github.EncryptedSecret{
+				Name:                  name,
+				KeyID:                 key.GetKeyID(),
+				EncryptedValue:        encryptedBytes,
+				Visibility:            access.Visibility,
+				SelectedRepositoryIDs: selectedRepoIDs,
+			}
+			resp, err := c.ghClient.Actions.CreateOrUpdateOrgSecret(ctx, org, secret)
+			if err != nil {
+				return resp, fmt.Errorf("failed to create/update organization secret: %w", err)
+			}
+			return resp, nil
+		},
+	)
+}
+
+// DeleteOrgSecret deletes a GitHub Actions organization secret, a secret already gone is not considered an error
+func (c *client) DeleteOrgSecret(ctx context.Context, org, name string) error {
+	resp, err := c.ghClient.Actions.DeleteOrgSecret(ctx, org, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
 		}
+		return fmt.Errorf("failed to delete organization secret: %w", err)
+	}
+	return nil
+}
 
-		// Check if we hit rate limiting
-		if resp.StatusCode == http.StatusForbidden {
-			rateLimitReset := resp.Header.Get("X-RateLimit-Reset")
-			if rateLimitReset != "" {
-				resetTime, parseErr := strconv.ParseInt(rateLimitReset, 10, 64)
-				if parseErr == nil {
-					waitDuration := time.Until(time.Unix(resetTime, 0))
-					if waitDuration > 0 {
-						time.Sleep(waitDuration)
-						continue
-					}
-				}
+// CreateOrUpdateOrgVariable creates or updates a GitHub Actions organization variable
+func (c *client) CreateOrUpdateOrgVariable(ctx context.Context, org, name, value string, access OrgAccess) error {
+	// Resolve repositories allowed to access the variable
+	selectedRepoIDs, err := c.resolveOrgAccess(ctx, org, access)
+	if err != nil {
+		return err
+	}
+
+	//
+	variable := &github.ActionsVariable{
+		Name:       name,
+		Value:      value,
+		Visibility: github.String(access.Visibility),
+	}
+	if selectedRepoIDs != nil {
+		variable.SelectedRepositoryIDs = &selectedRepoIDs
+	}
+
+	//
+	resp, err := c.ghClient.Actions.UpdateOrgVariable(ctx, org, variable)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			// Variable doesn't exist, create it
+			_, err = c.ghClient.Actions.CreateOrgVariable(ctx, org, variable)
+			if err != nil {
+				return fmt.Errorf("failed to create organization variable: %w", err)
 			}
+		} else {
+			return fmt.Errorf("failed to update organization variable: %w", err)
 		}
+	}
+
+	return nil
+}
 
-		// If we get a server error, retry with exponential backoff
-		if resp.StatusCode >= 500 && i < maxRetries-1 {
-			time.Sleep(backoff)
-			backoff *= 2
-			continue
+// DeleteOrgVariable deletes a GitHub Actions organization variable, a variable already gone is not considered an error
+func (c *client) DeleteOrgVariable(ctx context.Context, org, name string) error {
+	resp, err := c.ghClient.Actions.DeleteOrgVariable(ctx, org, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
 		}
+		return fmt.Errorf("failed to delete organization variable: %w", err)
+	}
+	return nil
+}
 
-		break
+// resolveOrgAccess looks up the IDs of the selected repositories, GitHub API only accepting those.
+// Returns nil when the visibility does not restrict to selected repositories.
+func (c *client) resolveOrgAccess(ctx context.Context, org string, access OrgAccess) (github.SelectedRepoIDs, error) {
+	if access.Visibility != "selected" {
+		return nil, nil
+	}
+
+	//
+	ids := github.SelectedRepoIDs{}
+	for _, repoName := range access.SelectedRepositories {
+		repoID, err := c.repositoryID(ctx, org, repoName)
+		if err != nil {
+			return nil, fmt.Errorf("selected repository '%s/%s': %w", org, repoName, err)
+		}
+		ids = append(ids, int64(repoID))
 	}
 
-	return resp, err
+	return ids, nil
 }
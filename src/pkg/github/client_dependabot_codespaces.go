@@ -0,0 +1,205 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// Public keys for secret encryption are cached like Actions ones, Dependabot and Codespaces each having their own
+
+// CreateOrUpdateDependabotSecret creates or updates a Dependabot secret
+func (c *client) CreateOrUpdateDependabotSecret(ctx context.Context, owner, repo, name string, value []byte) error {
+	return c.pushWithPublicKey("dependabot/"+owner+"/"+repo,
+		func() (*github.PublicKey, error) {
+			key, _, err := c.ghClient.Dependabot.GetRepoPublicKey(ctx, owner, repo)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get repository Dependabot public key: %w", err)
+			}
+			return key, nil
+		},
+		func(key *github.PublicKey) (*github.Response, error) {
+			// Encrypt secret value using sodium library
+			encryptedBytes, err := encryptSecretWithPublicKey(value, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+			}
+
+			// Create or update secret
+			secret := &github.DependabotEncryptedSecret{
+				Name:           name,
+				KeyID:          key.GetKeyID(),
+				EncryptedValue: encryptedBytes,
+			}
+			resp, err := c.ghClient.Dependabot.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
+			if err != nil {
+				return resp, fmt.Errorf("failed to create/update Dependabot secret: %w", err)
+			}
+			return resp, nil
+		},
+	)
+}
+
+// DeleteDependabotSecret deletes a Dependabot secret, a secret already gone is not considered an error
+func (c *client) DeleteDependabotSecret(ctx context.Context, owner, repo, name string) error {
+	resp, err := c.ghClient.Dependabot.DeleteRepoSecret(ctx, owner, repo, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete Dependabot secret: %w", err)
+	}
+	return nil
+}
+
+// CreateOrUpdateOrgDependabotSecret creates or updates a Dependabot organization secret
+func (c *client) CreateOrUpdateOrgDependabotSecret(ctx context.Context, org, name string, value []byte, access OrgAccess) error {
+	// Resolve repositories allowed to access the secret
+	selectedRepoIDs, err := c.resolveOrgAccess(ctx, org, access)
+	if err != nil {
+		return err
+	}
+
+	//
+	return c.pushWithPublicKey("dependabot/"+org,
+		func() (*github.PublicKey, error) {
+			key, _, err := c.ghClient.Dependabot.GetOrgPublicKey(ctx, org)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get organization Dependabot public key: %w", err)
+			}
+			return key, nil
+		},
+		func(key *github.PublicKey) (*github.Response, error) {
+			// Encrypt secret value using sodium library
+			encryptedBytes, err := encryptSecretWithPublicKey(value, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+			}
+
+			// Create or update secret
+			secret := &github.DependabotEncryptedSecret{
+				Name:                  name,
+				KeyID:                 key.GetKeyID(),
+				EncryptedValue:        encryptedBytes,
+				Visibility:            access.Visibility,
+				SelectedRepositoryIDs: github.DependabotSecretsSelectedRepoIDs(selectedRepoIDs),
+			}
+			resp, err := c.ghClient.Dependabot.CreateOrUpdateOrgSecret(ctx, org, secret)
+			if err != nil {
+				return resp, fmt.Errorf("failed to create/update organization Dependabot secret: %w", err)
+			}
+			return resp, nil
+		},
+	)
+}
+
+// DeleteOrgDependabotSecret deletes a Dependabot organization secret, a secret already gone is not considered an error
+func (c *client) DeleteOrgDependabotSecret(ctx context.Context, org, name string) error {
+	resp, err := c.ghClient.Dependabot.DeleteOrgSecret(ctx, org, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete organization Dependabot secret: %w", err)
+	}
+	return nil
+}
+
+// CreateOrUpdateCodespacesSecret creates or updates a Codespaces secret
+func (c *client) CreateOrUpdateCodespacesSecret(ctx context.Context, owner, repo, name string, value []byte) error {
+	return c.pushWithPublicKey("codespaces/"+owner+"/"+repo,
+		func() (*github.PublicKey, error) {
+			key, _, err := c.ghClient.Codespaces.GetRepoPublicKey(ctx, owner, repo)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get repository Codespaces public key: %w", err)
+			}
+			return key, nil
+		},
+		func(key *github.PublicKey) (*github.Response, error) {
+			// Encrypt secret value using sodium library
+			encryptedBytes, err := encryptSecretWithPublicKey(value, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+			}
+
+			// Create or update secret
+			secret := &github.EncryptedSecret{
+				Name:           name,
+				KeyID:          key.GetKeyID(),
+				EncryptedValue: encryptedBytes,
+			}
+			resp, err := c.ghClient.Codespaces.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
+			if err != nil {
+				return resp, fmt.Errorf("failed to create/update Codespaces secret: %w", err)
+			}
+			return resp, nil
+		},
+	)
+}
+
+// DeleteCodespacesSecret deletes a Codespaces secret, a secret already gone is not considered an error
+func (c *client) DeleteCodespacesSecret(ctx context.Context, owner, repo, name string) error {
+	resp, err := c.ghClient.Codespaces.DeleteRepoSecret(ctx, owner, repo, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete Codespaces secret: %w", err)
+	}
+	return nil
+}
+
+// CreateOrUpdateOrgCodespacesSecret creates or updates a Codespaces organization secret
+func (c *client) CreateOrUpdateOrgCodespacesSecret(ctx context.Context, org, name string, value []byte, access OrgAccess) error {
+	// Resolve repositories allowed to access the secret
+	selectedRepoIDs, err := c.resolveOrgAccess(ctx, org, access)
+	if err != nil {
+		return err
+	}
+
+	//
+	return c.pushWithPublicKey("codespaces/"+org,
+		func() (*github.PublicKey, error) {
+			key, _, err := c.ghClient.Codespaces.GetOrgPublicKey(ctx, org)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get organization Codespaces public key: %w", err)
+			}
+			return key, nil
+		},
+		func(key *github.PublicKey) (*github.Response, error) {
+			// Encrypt secret value using sodium library
+			encryptedBytes, err := encryptSecretWithPublicKey(value, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+			}
+
+			// Create or update secret
+			secret := &github.EncryptedSecret{
+				Name:                  name,
+				KeyID:                 key.GetKeyID(),
+				EncryptedValue:        encryptedBytes,
+				Visibility:            access.Visibility,
+				SelectedRepositoryIDs: selectedRepoIDs,
+			}
+			resp, err := c.ghClient.Codespaces.CreateOrUpdateOrgSecret(ctx, org, secret)
+			if err != nil {
+				return resp, fmt.Errorf("failed to create/update organization Codespaces secret: %w", err)
+			}
+			return resp, nil
+		},
+	)
+}
+
+// DeleteOrgCodespacesSecret deletes a Codespaces organization secret, a secret already gone is not considered an error
+func (c *client) DeleteOrgCodespacesSecret(ctx context.Context, org, name string) error {
+	resp, err := c.ghClient.Codespaces.DeleteOrgSecret(ctx, org, name)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete organization Codespaces secret: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// RemoteSecret is a secret as listed by GitHub, which never discloses its value
+type RemoteSecret struct {
+	Name      string
+	UpdatedAt time.Time
+}
+
+// RemoteVariable is a variable as listed by GitHub
+type RemoteVariable struct {
+	Name      string
+	Value     string
+	UpdatedAt time.Time
+}
+
+// ListSecrets lists the GitHub Actions secrets of a repository
+func (c *client) ListSecrets(ctx context.Context, owner, repo string) ([]RemoteSecret, error) {
+	return listSecretPages(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return c.ghClient.Actions.ListRepoSecrets(ctx, owner, repo, opts)
+	})
+}
+
+// ListVariables lists the GitHub Actions variables of a repository
+func (c *client) ListVariables(ctx context.Context, owner, repo string) ([]RemoteVariable, error) {
+	return listVariablePages(func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+		return c.ghClient.Actions.ListRepoVariables(ctx, owner, repo, opts)
+	})
+}
+
+// ListEnvSecrets lists the GitHub Actions secrets of a repository environment
+func (c *client) ListEnvSecrets(ctx context.Context, owner, repo, env string) ([]RemoteSecret, error) {
+	repoID, err := c.repositoryID(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return listSecretPages(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return c.ghClient.Actions.ListEnvSecrets(ctx, repoID, env, opts)
+	})
+}
+
+// ListEnvVariables lists the GitHub Actions variables of a repository environment
+func (c *client) ListEnvVariables(ctx context.Context, owner, repo, env string) ([]RemoteVariable, error) {
+	repoID, err := c.repositoryID(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return listVariablePages(func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+		return c.ghClient.Actions.ListEnvVariables(ctx, repoID, env, opts)
+	})
+}
+
+//
+//
+//
+
+func listSecretPages(list func(opts *github.ListOptions) (*github.Secrets, *github.Response, error)) ([]RemoteSecret, error) {
+	secrets := []RemoteSecret{}
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		page, resp, err := list(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+
+		for _, secret := range page.Secrets {
+			secrets = append(secrets, RemoteSecret{
+				Name:      secret.Name,
+				UpdatedAt: secret.UpdatedAt.Time,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			return secrets, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func listVariablePages(list func(opts *github.ListOptions) (*github.ActionsVariables, *github.Response, error)) ([]RemoteVariable, error) {
+	variables := []RemoteVariable{}
+	// variables endpoints do not allow bigger pages
+	opts := &github.ListOptions{PerPage: 30}
+
+	for {
+		page, resp, err := list(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list variables: %w", err)
+		}
+
+		for _, variable := range page.Variables {
+			remote := RemoteVariable{
+				Name:  variable.Name,
+				Value: variable.Value,
+			}
+			if variable.UpdatedAt != nil {
+				remote.UpdatedAt = variable.UpdatedAt.Time
+			}
+			variables = append(variables, remote)
+		}
+
+		if resp.NextPage == 0 {
+			return variables, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,137 @@
+package github
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+// How long a public key is trusted before being fetched again
+const publicKeyTTL = time.Hour
+
+// How long a repository ID is trusted before being looked up again. IDs never change,
+// but a repository deleted then created again under the same name gets a new one.
+const repoIDTTL = 24 * time.Hour
+
+// publicKeyCache keeps the public keys used to encrypt secrets, sparing a call to GitHub API per secret pushed
+type publicKeyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedPublicKey
+}
+
+type cachedPublicKey struct {
+	key       *github.PublicKey
+	fetchedAt time.Time
+}
+
+func newPublicKeyCache(ttl time.Duration) *publicKeyCache {
+	return &publicKeyCache{
+		ttl:     ttl,
+		entries: map[string]cachedPublicKey{},
+	}
+}
+
+// get returns the cached public key, fetching it when missing or expired.
+// cached tells if the key was served from cache, thus possibly rotated since.
+func (c *publicKeyCache) get(cacheKey string, fetch func() (*github.PublicKey, error)) (key *github.PublicKey, cached bool, err error) {
+	c.mu.Lock()
+	entry, found := c.entries[cacheKey]
+	c.mu.Unlock()
+
+	if found && time.Since(entry.fetchedAt) < c.ttl {
+		return entry.key, true, nil
+	}
+
+	//
+	key, err = fetch()
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.mu.Lock()
+	c.entries[cacheKey] = cachedPublicKey{key: key, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return key, false, nil
+}
+
+func (c *publicKeyCache) invalidate(cacheKey string) {
+	c.mu.Lock()
+	delete(c.entries, cacheKey)
+	c.mu.Unlock()
+}
+
+// repoIDCache keeps the IDs environment endpoints expect, sparing a call to GitHub API per environment property
+type repoIDCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedRepoID
+}
+
+type cachedRepoID struct {
+	id        int
+	fetchedAt time.Time
+}
+
+func newRepoIDCache(ttl time.Duration) *repoIDCache {
+	return &repoIDCache{
+		ttl:     ttl,
+		entries: map[string]cachedRepoID{},
+	}
+}
+
+// get returns the cached repository ID, fetching it when missing or expired
+func (c *repoIDCache) get(cacheKey string, fetch func() (int, error)) (int, error) {
+	c.mu.Lock()
+	entry, found := c.entries[cacheKey]
+	c.mu.Unlock()
+
+	if found && time.Since(entry.fetchedAt) < c.ttl {
+		return entry.id, nil
+	}
+
+	//
+	id, err := fetch()
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.entries[cacheKey] = cachedRepoID{id: id, fetchedAt: time.Now()}
+	c.mu.Unlock()
+
+	return id, nil
+}
+
+// pushWithPublicKey pushes a secret encrypted with the public key identified by cacheKey.
+// Should GitHub reject a cached key, it may have been rotated: the key is fetched again and the push retried once.
+func (c *client) pushWithPublicKey(cacheKey string, fetchKey func() (*github.PublicKey, error), push func(key *github.PublicKey) (*github.Response, error)) error {
+	key, cached, err := c.publicKeys.get(cacheKey, fetchKey)
+	if err != nil {
+		return err
+	}
+
+	//
+	resp, err := push(key)
+	if err == nil || !cached || !isKeyRejected(resp) {
+		return err
+	}
+
+	//
+	c.publicKeys.invalidate(cacheKey)
+	key, _, err = c.publicKeys.get(cacheKey, fetchKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = push(key)
+	return err
+}
+
+// isKeyRejected tells if GitHub refused the encrypted value, as it does when the key_id is not the current one
+func isKeyRejected(resp *github.Response) bool {
+	return resp != nil && (resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity)
+}
@@ -0,0 +1,212 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+const (
+	// Attempts made for a single request, retries included
+	maxAttempts = 3
+	// First delay before retrying a request that failed server-side, doubled on each retry
+	serverErrorBackoff = time.Second
+	// Rate limits lifting sooner than this are waited for in place, later ones surface as RateLimitError
+	maxRateLimitWait = 10 * time.Second
+	// Secondary rate limits not telling when they lift are waited for at least this long, as advised by GitHub
+	defaultSecondaryRateLimitWait = time.Minute
+)
+
+// RateLimitError tells GitHub API refuses requests until a given time
+type RateLimitError struct {
+	Until time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited by GitHub API until %s", e.Until.Format(time.RFC3339))
+}
+
+// RateLimitedUntil tells if err is due to rate limiting, and until when.
+// Besides limits met by the transport, go-github fails early once it knows the quota exhausted,
+// and surfaces secondary rate limits the transport gave up waiting for.
+func RateLimitedUntil(err error) (time.Time, bool) {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr.Until, true
+	}
+
+	//
+	var primaryErr *github.RateLimitError
+	if errors.As(err, &primaryErr) {
+		return primaryErr.Rate.Reset.Time, true
+	}
+
+	//
+	var secondaryErr *github.AbuseRateLimitError
+	if errors.As(err, &secondaryErr) {
+		wait := secondaryErr.GetRetryAfter()
+		if wait <= 0 {
+			wait = defaultSecondaryRateLimitWait
+		}
+		return time.Now().Add(wait), true
+	}
+
+	return time.Time{}, false
+}
+
+// Observer is told about every request made to GitHub API, e.g. to expose metrics
+type Observer interface {
+	// ObserveRequest records a request outcome, code being the HTTP status code or "error" when none came back
+	ObserveRequest(method, code string, duration time.Duration)
+	// ObserveRateLimitRemaining records the requests GitHub API still accepts for a rate limit resource
+	ObserveRateLimitRemaining(resource string, remaining float64)
+}
+
+// rateLimitTransport retries requests failing server-side and handles primary and secondary rate limits.
+// It never waits beyond the request context, nor for long: a limit lifting later is surfaced as a RateLimitError,
+// so that callers can come back later instead of holding on.
+type rateLimitTransport struct {
+	base     http.RoundTripper
+	observer Observer
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := serverErrorBackoff
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		resp, err := t.base.RoundTrip(req)
+		t.observe(req, resp, err, start)
+		if err != nil {
+			return nil, err
+		}
+
+		//
+		var wait time.Duration
+		if until, limited := rateLimitedUntil(resp); limited {
+			wait = time.Until(until)
+			if wait > maxRateLimitWait {
+				resp.Body.Close()
+				return nil, &RateLimitError{Until: until}
+			}
+		} else if resp.StatusCode >= 500 {
+			wait = backoff
+			backoff *= 2
+		} else {
+			return resp, nil
+		}
+
+		// out of attempts, or unable to send the body again
+		if attempt >= maxAttempts {
+			return resp, nil
+		}
+		next, err := rewind(req)
+		if err != nil {
+			return resp, nil
+		}
+
+		//
+		resp.Body.Close()
+		if err := sleepCtx(req, wait); err != nil {
+			return nil, err
+		}
+		req = next
+	}
+}
+
+// observe reports the request outcome and the rate limit budget left, if anyone listens
+func (t *rateLimitTransport) observe(req *http.Request, resp *http.Response, err error, start time.Time) {
+	if t.observer == nil {
+		return
+	}
+
+	//
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	t.observer.ObserveRequest(req.Method, code, time.Since(start))
+
+	//
+	if err != nil {
+		return
+	}
+	remaining, parseErr := strconv.ParseFloat(resp.Header.Get("X-RateLimit-Remaining"), 64)
+	if parseErr != nil {
+		return
+	}
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+	t.observer.ObserveRateLimitRemaining(resource, remaining)
+}
+
+// rateLimitedUntil tells if the response is a refusal due to primary or secondary rate limiting, and until when
+func rateLimitedUntil(resp *http.Response) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+
+	// secondary rate limit, telling how many seconds to wait
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.ParseInt(retryAfter, 10, 64); err == nil {
+			return time.Now().Add(time.Duration(seconds) * time.Second), true
+		}
+	}
+
+	// primary rate limit, telling when quota resets
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0), true
+		}
+	}
+
+	// secondary rate limit, not telling anything
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Now().Add(defaultSecondaryRateLimitWait), true
+	}
+
+	// plain permission issue
+	return time.Time{}, false
+}
+
+// rewind produces a copy of the request able to be sent again, its body having been consumed
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be rewound")
+	}
+
+	//
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next, nil
+}
+
+// sleepCtx waits for the given duration, unless the request context ends first
+func sleepCtx(req *http.Request, wait time.Duration) error {
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
@@ -0,0 +1,169 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v60/github"
+)
+
+func TestRateLimitedUntil(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	retryAfter := 2 * time.Minute
+
+	tests := []struct {
+		name        string
+		err         error
+		wantLimited bool
+		// expected wait from now, when until is not known upfront
+		wantWait  time.Duration
+		wantExact time.Time
+	}{
+		{
+			name:        "transport rate limit",
+			err:         &RateLimitError{Until: reset},
+			wantLimited: true,
+			wantExact:   reset,
+		},
+		{
+			name:        "transport rate limit wrapped by http client",
+			err:         &url.Error{Op: "Put", URL: "https://api.github.com", Err: &RateLimitError{Until: reset}},
+			wantLimited: true,
+			wantExact:   reset,
+		},
+		{
+			name:        "primary rate limit known by go-github",
+			err:         &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}},
+			wantLimited: true,
+			wantExact:   reset,
+		},
+		{
+			name:        "wrapped primary rate limit",
+			err:         fmt.Errorf("failed to get public key: %w", &github.RateLimitError{Rate: github.Rate{Reset: github.Timestamp{Time: reset}}}),
+			wantLimited: true,
+			wantExact:   reset,
+		},
+		{
+			name:        "secondary rate limit telling when it lifts",
+			err:         &github.AbuseRateLimitError{RetryAfter: &retryAfter},
+			wantLimited: true,
+			wantWait:    retryAfter,
+		},
+		{
+			name:        "secondary rate limit not telling when it lifts",
+			err:         &github.AbuseRateLimitError{},
+			wantLimited: true,
+			wantWait:    defaultSecondaryRateLimitWait,
+		},
+		{
+			name:        "plain error",
+			err:         &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusForbidden}},
+			wantLimited: false,
+		},
+		{
+			name:        "no error",
+			err:         nil,
+			wantLimited: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			until, limited := RateLimitedUntil(tt.err)
+			if limited != tt.wantLimited {
+				t.Fatalf("limited = %v, want %v", limited, tt.wantLimited)
+			}
+			if !tt.wantLimited {
+				return
+			}
+
+			//
+			if !tt.wantExact.IsZero() {
+				if !until.Equal(tt.wantExact) {
+					t.Errorf("until = %s, want %s", until, tt.wantExact)
+				}
+				return
+			}
+			if until.Before(before.Add(tt.wantWait)) || until.After(time.Now().Add(tt.wantWait)) {
+				t.Errorf("until = %s, want about %s from now", until, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRateLimitedUntilResponse(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name        string
+		status      int
+		headers     map[string]string
+		wantLimited bool
+		wantWait    time.Duration
+		wantExact   time.Time
+	}{
+		{
+			name:        "retry after",
+			status:      http.StatusForbidden,
+			headers:     map[string]string{"Retry-After": "5"},
+			wantLimited: true,
+			wantWait:    5 * time.Second,
+		},
+		{
+			name:        "quota exhausted",
+			status:      http.StatusForbidden,
+			headers:     map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": fmt.Sprint(reset.Unix())},
+			wantLimited: true,
+			wantExact:   reset,
+		},
+		{
+			name:        "too many requests without hint",
+			status:      http.StatusTooManyRequests,
+			wantLimited: true,
+			wantWait:    defaultSecondaryRateLimitWait,
+		},
+		{
+			name:        "forbidden for lack of permission",
+			status:      http.StatusForbidden,
+			headers:     map[string]string{"X-RateLimit-Remaining": "4000"},
+			wantLimited: false,
+		},
+		{
+			name:        "success",
+			status:      http.StatusOK,
+			wantLimited: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for key, value := range tt.headers {
+				resp.Header.Set(key, value)
+			}
+
+			//
+			before := time.Now()
+			until, limited := rateLimitedUntil(resp)
+			if limited != tt.wantLimited {
+				t.Fatalf("limited = %v, want %v", limited, tt.wantLimited)
+			}
+			if !tt.wantLimited {
+				return
+			}
+			if !tt.wantExact.IsZero() {
+				if !until.Equal(tt.wantExact) {
+					t.Errorf("until = %s, want %s", until, tt.wantExact)
+				}
+				return
+			}
+			if until.Before(before.Add(tt.wantWait)) || until.After(time.Now().Add(tt.wantWait)) {
+				t.Errorf("until = %s, want about %s from now", until, tt.wantWait)
+			}
+		})
+	}
+}